@@ -1,6 +1,8 @@
 package robomaster2
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/brunoga/robomaster2/internal/robot/service"
@@ -9,6 +11,7 @@ import (
 	"github.com/brunoga/robomaster2/modules/chassis"
 	"github.com/brunoga/robomaster2/modules/finder"
 	"github.com/brunoga/robomaster2/modules/gimbal"
+	"github.com/brunoga/robomaster2/modules/robot"
 	"github.com/brunoga/robomaster2/modules/video"
 	"github.com/brunoga/robomaster2/support"
 )
@@ -19,6 +22,7 @@ type Client struct {
 	finder *finder.Finder
 	cc     service.DJICommandController
 
+	robot   *robot.Robot
 	chassis *chassis.Chassis
 	gimbal  *gimbal.Gimbal
 	video   *video.Video
@@ -27,11 +31,23 @@ type Client struct {
 func NewClient(logger *support.Logger) (*Client, error) {
 	cc := service.DJICommandControllerInstance()
 
+	f := finder.New(logger)
+
+	registry, err := loadDefaultRegistry()
+	if err != nil {
+		logger.WARNING("Known robots will not be remembered: %s", err.Error())
+	} else {
+		f.SetRegistry(registry)
+	}
+
+	r := robot.NewRobot(logger)
+
 	return &Client{
 		logger,
-		finder.New(logger),
+		f,
 		cc,
-		chassis.New(logger),
+		r,
+		chassis.New(logger, r),
 		gimbal.New(logger),
 		video.New(logger),
 	}, nil
@@ -46,6 +62,7 @@ func (c *Client) Start() error {
 		func(result *dji.DJIResult) {
 			if result.Value().(bool) {
 				c.logger.INFO("Connected to Robot.")
+				go c.updateRegistry()
 			}
 		}, false)
 
@@ -57,6 +74,13 @@ func (c *Client) Start() error {
 	// Send ack.
 	c.finder.SendACK()
 
+	route, err := c.finder.Route()
+	if err != nil {
+		c.logger.WARNING("Could not determine route to robot: %s", err.Error())
+	} else {
+		c.logger.INFO("Reaching robot through %s.", route)
+	}
+
 	ub.SendEventWithoutDataOrTag(
 		unitybridge.NewDJIUnityEventWithTypeAndSubType(
 			unitybridge.Connection, 1))
@@ -74,11 +98,87 @@ func (c *Client) Start() error {
 }
 
 func (c *Client) Stop() {
+	c.chassis.Stop()
+	c.robot.Stop()
+	c.gimbal.Stop()
 	c.video.Stop()
 	c.cc.UnInit()
 	unitybridge.DJIUnityBridgeInstance().UnInit()
 }
 
+// Finder returns the Finder used to find and pair with the robot. It can be
+// used to select the network interface to use before calling Start.
+func (c *Client) Finder() *finder.Finder {
+	return c.finder
+}
+
+// Route returns how the robot is reached: the local interface and address used
+// and the robot connection mode. Only valid after Start succeeds.
+func (c *Client) Route() (finder.Route, error) {
+	return c.finder.Route()
+}
+
+// updateRegistry records the robot we are connected to in the registry of
+// known robots, together with its serial number, product type and firmware
+// versions.
+func (c *Client) updateRegistry() {
+	if c.finder.Registry() == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info := finder.KnownRobot{
+		FirmwareVersions: make(map[string]string),
+	}
+
+	result, err := service.GetValueForKeySync(ctx, c.cc,
+		dji.DJIRobomasterSystemSerialNumber)
+	if err == nil {
+		info.Serial = fmt.Sprint(result.Value())
+	}
+
+	result, err = service.GetValueForKeySync(ctx, c.cc, dji.DJIProductType)
+	if err == nil {
+		if productType, ok := result.Value().(float64); ok {
+			info.ProductType = int64(productType)
+		}
+	}
+
+	for component, key := range map[string]dji.DJIKeys{
+		"camera":          dji.DJICameraFirmwareVersion,
+		"gimbal":          dji.DJIGimbalFirmwareVersion,
+		"main_controller": dji.DJIMainControllerFirmwareVersion,
+		"system":          dji.DJIRobomasterSystemFirmwareVersion,
+	} {
+		result, err = service.GetValueForKeySync(ctx, c.cc, key)
+		if err == nil {
+			info.FirmwareVersions[component] = fmt.Sprint(result.Value())
+		}
+	}
+
+	err = c.finder.UpdateRegistry(info)
+	if err != nil {
+		c.logger.WARNING("Could not update known robots: %s", err.Error())
+	}
+}
+
+func loadDefaultRegistry() (*finder.Registry, error) {
+	path, err := finder.DefaultRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return finder.LoadRegistry(path)
+}
+
+// Robot returns the Robot used to send virtual stick commands. The Chassis
+// drives the robot through it too.
+func (c *Client) Robot() *robot.Robot {
+	return c.robot
+}
+
 func (c *Client) Chassis() *chassis.Chassis {
 	return c.chassis
 }
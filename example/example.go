@@ -1,19 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/brunoga/robomaster2"
+	"github.com/brunoga/robomaster2/modules/gimbal"
 	"github.com/brunoga/robomaster2/modules/video"
 	"github.com/brunoga/robomaster2/support"
 )
 
 type ExampleVideoHandler struct{}
 
-func (h *ExampleVideoHandler) HandleVideo(img *video.RGB) {
-	fmt.Println("Got frame!")
+func (h *ExampleVideoHandler) HandleVideo(frame *video.Frame) {
+	fmt.Printf("Got frame %d!\n", frame.Seq)
 }
 
 func main() {
@@ -35,7 +37,15 @@ func main() {
 
 	time.Sleep(2 * time.Second)
 
-	c.Gimbal().MoveToRelativeAngle(20, 0, 1.0)
+	err = c.Gimbal().Move(context.Background(), gimbal.Target{
+		Pitch:    20,
+		Duration: time.Second,
+		Mode:     gimbal.MoveModeRelative,
+		Axes:     gimbal.AxisPitch,
+	})
+	if err != nil {
+		l.ERROR("Error moving gimbal: %s", err.Error())
+	}
 
 	time.Sleep(10 * time.Second)
 }
@@ -0,0 +1,7 @@
+package dji
+
+type DJIChassisSpeedParamValue struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
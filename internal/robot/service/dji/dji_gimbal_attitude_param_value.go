@@ -0,0 +1,8 @@
+package dji
+
+type DJIGimbalAttitudeParamValue struct {
+	Pitch       float64 `json:"pitch"`
+	Yaw         float64 `json:"yaw"`
+	Roll        float64 `json:"roll"`
+	YawOpposite float64 `json:"yaw_opposite"`
+}
@@ -0,0 +1,13 @@
+package dji
+
+type DJIGimbalSpeedRotationParamValue struct {
+	Pitch int16 `json:"pitch"`
+	Yaw   int16 `json:"yaw"`
+}
+
+func NewDJIGimbalSpeedRotationParamValue(pitch, yaw int16) *DJIGimbalSpeedRotationParamValue {
+	return &DJIGimbalSpeedRotationParamValue{
+		Pitch: pitch,
+		Yaw:   yaw,
+	}
+}
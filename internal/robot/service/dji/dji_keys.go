@@ -357,49 +357,163 @@ const (
 
 var (
 	keyAttributeMap = map[DJIKeys]keyAttributes{
-		DJIAirLinkConnection:                 {117440513, typeof[DJIBoolParamValue](), AccessType_Read},
-		DJIGimbalAngleIncrementRotation:      {67108875, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
-		DJIGimbalAngleFrontPitchRotation:     {67108877, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
-		DJIGimbalAngleFrontYawRotation:       {67108876, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
-		DJIGimbalConnection:                  {67108865, typeof[DJIBoolParamValue](), AccessType_Read},
-		DJIGimbalOpenAttitudeUpdates:         {67108882, nil, AccessType_Action},
-		DJIGimbalResetPosition:               {67108870, typeof[DJIBoolParamValue](), AccessType_Action},
-		DJIMainControllerVirtualStick:        {33554436, typeof[DJIRealControlParamValue](), AccessType_Action},
-		DJIRobomasterOpenChassisSpeedUpdates: {33554474, nil, AccessType_Action},
-		DJIRobomasterSystemConnection:        {83886081, typeof[DJIBoolParamValue](), AccessType_Read},
+		DJIAirLinkConnection:                        {117440513, typeof[DJIBoolParamValue](), AccessType_Read},
+		DJICameraFirmwareVersion:                    {16777218, typeof[DJIStringParamValue](), AccessType_Read},
+		DJICameraVideoFormat:                        {16777226, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIGimbalAngleIncrementRotation:             {67108875, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
+		DJIGimbalAngleFrontPitchRotation:            {67108877, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
+		DJIGimbalAngleFrontYawRotation:              {67108876, typeof[DJIGimbalAngleRotationParamValue](), AccessType_Action},
+		DJIGimbalAttitude:                           {67108878, typeof[DJIGimbalAttitudeParamValue](), AccessType_Read},
+		DJIGimbalAutoCalibrate:                      {67108879, nil, AccessType_Action},
+		DJIGimbalCalibration:                        {67108872, nil, AccessType_Action},
+		DJIGimbalCalibrationProgress:                {67108881, typeof[DJILongParamValue](), AccessType_Read},
+		DJIGimbalCalibrationStatus:                  {67108880, typeof[DJILongParamValue](), AccessType_Read},
+		DJIGimbalCloseAttitudeUpdates:               {67108883, nil, AccessType_Action},
+		DJIGimbalConnection:                         {67108865, typeof[DJIBoolParamValue](), AccessType_Read},
+		DJIGimbalControlMode:                        {67108869, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIGimbalFirmwareVersion:                    {67108867, typeof[DJIStringParamValue](), AccessType_Read},
+		DJIGimbalOpenAttitudeUpdates:                {67108882, nil, AccessType_Action},
+		DJIGimbalResetPosition:                      {67108870, typeof[DJIBoolParamValue](), AccessType_Action},
+		DJIGimbalResetPositionState:                 {67108871, typeof[DJILongParamValue](), AccessType_Read},
+		DJIGimbalSpeedRotation:                      {67108873, typeof[DJIGimbalSpeedRotationParamValue](), AccessType_Action},
+		DJIGimbalSpeedRotationEnabled:               {67108874, typeof[DJIBoolParamValue](), AccessType_Read | AccessType_Write},
+		DJIGimbalWorkMode:                           {67108868, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerChassisCarControlMode:      {33554440, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerChassisFollowMode:          {33554439, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerChassisPosition:            {33554461, typeof[DJIChassisPositionParamValue](), AccessType_Read},
+		DJIMainControllerChassisSpeedMode:           {33554438, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerFirmwareVersion:            {33554434, typeof[DJIStringParamValue](), AccessType_Read},
+		DJIMainControllerMaxSpeedBackward:           {33554448, typeof[DJIFloatParamValue](), AccessType_Read},
+		DJIMainControllerMaxSpeedBackwardConfig:     {33554455, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerMaxSpeedForward:            {33554447, typeof[DJIFloatParamValue](), AccessType_Read},
+		DJIMainControllerMaxSpeedForwardConfig:      {33554454, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerMaxSpeedLateral:            {33554449, typeof[DJIFloatParamValue](), AccessType_Read},
+		DJIMainControllerMaxSpeedLateralConfig:      {33554456, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerSlopBreakXConfig:           {33554460, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerSlopBreakYConfig:           {33554459, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerSlopSpeedXConfig:           {33554458, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerSlopSpeedYConfig:           {33554457, typeof[DJIFloatParamValue](), AccessType_Read | AccessType_Write},
+		DJIMainControllerVirtualStick:               {33554436, typeof[DJIRealControlParamValue](), AccessType_Action},
+		DJIMainControllerWheelSpeed:                 {33554462, typeof[DJIWheelSpeedParamValue](), AccessType_Read},
+		DJIProductType:                              {2, typeof[DJILongParamValue](), AccessType_Read},
+		DJIRobomasterChassisMode:                    {33554472, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIRobomasterChassisSpeed:                   {33554473, typeof[DJIChassisSpeedParamValue](), AccessType_Read},
+		DJIRobomasterCloseChassisSpeedUpdates:       {33554475, nil, AccessType_Action},
+		DJIRobomasterMainControllerRelativePosition: {33554476, typeof[DJIChassisPositionParamValue](), AccessType_Read},
+		DJIRobomasterOpenChassisSpeedUpdates:        {33554474, nil, AccessType_Action},
+		DJIRobomasterSystemChassisSpeedLevel:        {83886141, typeof[DJILongParamValue](), AccessType_Read | AccessType_Write},
+		DJIRobomasterSystemConnection:               {83886081, typeof[DJIBoolParamValue](), AccessType_Read},
+		DJIRobomasterSystemFirmwareVersion:          {83886082, typeof[DJIStringParamValue](), AccessType_Read},
+		DJIRobomasterSystemSerialNumber:             {83886085, typeof[DJIStringParamValue](), AccessType_Read},
 		// TODO(bga): Add any keys we need here.
 	}
 
 	keyNameMap = map[DJIKeys]string{
-		DJIAirLinkConnection:                 "AirLinkConnection",
-		DJICameraMode:                        "CameraMode",
-		DJICameraStartRecordVideo:            "CameraStartRecordVideo",
-		DJICameraStopRecordVideo:             "CameraStopRecordVideo",
-		DJIGimbalAngleIncrementRotation:      "GimbalAngleIncrementRotation",
-		DJIGimbalAngleFrontPitchRotation:     "GimbalAngleFrontPitchRotation",
-		DJIGimbalAngleFrontYawRotation:       "GimbalAngleFrontYawRotation",
-		DJIGimbalConnection:                  "GimbalConnection",
-		DJIGimbalOpenAttitudeUpdates:         "GimbalOpenAttitudeUpdates",
-		DJIGimbalResetPosition:               "GimbalResetPosition",
-		DJIMainControllerVirtualStick:        "MainControllerVirtualStick",
-		DJIRobomasterOpenChassisSpeedUpdates: "RobomasterOpenChassisSpeedUpdates",
-		DJIRobomasterSystemConnection:        "RobomasterSystemConnection",
+		DJIAirLinkConnection:                        "AirLinkConnection",
+		DJICameraFirmwareVersion:                    "CameraFirmwareVersion",
+		DJICameraMode:                               "CameraMode",
+		DJICameraStartRecordVideo:                   "CameraStartRecordVideo",
+		DJICameraStopRecordVideo:                    "CameraStopRecordVideo",
+		DJICameraVideoFormat:                        "CameraVideoFormat",
+		DJIGimbalAngleIncrementRotation:             "GimbalAngleIncrementRotation",
+		DJIGimbalAngleFrontPitchRotation:            "GimbalAngleFrontPitchRotation",
+		DJIGimbalAngleFrontYawRotation:              "GimbalAngleFrontYawRotation",
+		DJIGimbalAttitude:                           "GimbalAttitude",
+		DJIGimbalAutoCalibrate:                      "GimbalAutoCalibrate",
+		DJIGimbalCalibration:                        "GimbalCalibration",
+		DJIGimbalCalibrationProgress:                "GimbalCalibrationProgress",
+		DJIGimbalCalibrationStatus:                  "GimbalCalibrationStatus",
+		DJIGimbalCloseAttitudeUpdates:               "GimbalCloseAttitudeUpdates",
+		DJIGimbalConnection:                         "GimbalConnection",
+		DJIGimbalControlMode:                        "GimbalControlMode",
+		DJIGimbalFirmwareVersion:                    "GimbalFirmwareVersion",
+		DJIGimbalOpenAttitudeUpdates:                "GimbalOpenAttitudeUpdates",
+		DJIGimbalResetPosition:                      "GimbalResetPosition",
+		DJIGimbalResetPositionState:                 "GimbalResetPositionState",
+		DJIGimbalSpeedRotation:                      "GimbalSpeedRotation",
+		DJIGimbalSpeedRotationEnabled:               "GimbalSpeedRotationEnabled",
+		DJIGimbalWorkMode:                           "GimbalWorkMode",
+		DJIMainControllerChassisCarControlMode:      "MainControllerChassisCarControlMode",
+		DJIMainControllerChassisFollowMode:          "MainControllerChassisFollowMode",
+		DJIMainControllerChassisPosition:            "MainControllerChassisPosition",
+		DJIMainControllerChassisSpeedMode:           "MainControllerChassisSpeedMode",
+		DJIMainControllerFirmwareVersion:            "MainControllerFirmwareVersion",
+		DJIMainControllerMaxSpeedBackward:           "MainControllerMaxSpeedBackward",
+		DJIMainControllerMaxSpeedBackwardConfig:     "MainControllerMaxSpeedBackwardConfig",
+		DJIMainControllerMaxSpeedForward:            "MainControllerMaxSpeedForward",
+		DJIMainControllerMaxSpeedForwardConfig:      "MainControllerMaxSpeedForwardConfig",
+		DJIMainControllerMaxSpeedLateral:            "MainControllerMaxSpeedLateral",
+		DJIMainControllerMaxSpeedLateralConfig:      "MainControllerMaxSpeedLateralConfig",
+		DJIMainControllerSlopBreakXConfig:           "MainControllerSlopBreakXConfig",
+		DJIMainControllerSlopBreakYConfig:           "MainControllerSlopBreakYConfig",
+		DJIMainControllerSlopSpeedXConfig:           "MainControllerSlopSpeedXConfig",
+		DJIMainControllerSlopSpeedYConfig:           "MainControllerSlopSpeedYConfig",
+		DJIMainControllerVirtualStick:               "MainControllerVirtualStick",
+		DJIMainControllerWheelSpeed:                 "MainControllerWheelSpeed",
+		DJIProductType:                              "ProductType",
+		DJIRobomasterChassisMode:                    "RobomasterChassisMode",
+		DJIRobomasterChassisSpeed:                   "RobomasterChassisSpeed",
+		DJIRobomasterCloseChassisSpeedUpdates:       "RobomasterCloseChassisSpeedUpdates",
+		DJIRobomasterMainControllerRelativePosition: "RobomasterMainControllerRelativePosition",
+		DJIRobomasterOpenChassisSpeedUpdates:        "RobomasterOpenChassisSpeedUpdates",
+		DJIRobomasterSystemChassisSpeedLevel:        "RobomasterSystemChassisSpeedLevel",
+		DJIRobomasterSystemConnection:               "RobomasterSystemConnection",
+		DJIRobomasterSystemFirmwareVersion:          "RobomasterSystemFirmwareVersion",
+		DJIRobomasterSystemSerialNumber:             "RobomasterSystemSerialNumber",
 		// TODO(bga): Add any keys we need here.
 	}
 
 	keyByValueMap = map[int]DJIKeys{
+		2:         DJIProductType,
+		16777218:  DJICameraFirmwareVersion,
 		16777222:  DJICameraStartRecordVideo,
 		16777223:  DJICameraStopRecordVideo,
+		16777226:  DJICameraVideoFormat,
 		16777227:  DJICameraMode,
+		33554434:  DJIMainControllerFirmwareVersion,
 		33554436:  DJIMainControllerVirtualStick,
+		33554438:  DJIMainControllerChassisSpeedMode,
+		33554439:  DJIMainControllerChassisFollowMode,
+		33554440:  DJIMainControllerChassisCarControlMode,
+		33554447:  DJIMainControllerMaxSpeedForward,
+		33554448:  DJIMainControllerMaxSpeedBackward,
+		33554449:  DJIMainControllerMaxSpeedLateral,
+		33554454:  DJIMainControllerMaxSpeedForwardConfig,
+		33554455:  DJIMainControllerMaxSpeedBackwardConfig,
+		33554456:  DJIMainControllerMaxSpeedLateralConfig,
+		33554457:  DJIMainControllerSlopSpeedYConfig,
+		33554458:  DJIMainControllerSlopSpeedXConfig,
+		33554459:  DJIMainControllerSlopBreakYConfig,
+		33554460:  DJIMainControllerSlopBreakXConfig,
+		33554461:  DJIMainControllerChassisPosition,
+		33554462:  DJIMainControllerWheelSpeed,
+		33554472:  DJIRobomasterChassisMode,
+		33554473:  DJIRobomasterChassisSpeed,
 		33554474:  DJIRobomasterOpenChassisSpeedUpdates,
+		33554475:  DJIRobomasterCloseChassisSpeedUpdates,
+		33554476:  DJIRobomasterMainControllerRelativePosition,
 		67108865:  DJIGimbalConnection,
+		67108867:  DJIGimbalFirmwareVersion,
+		67108868:  DJIGimbalWorkMode,
+		67108869:  DJIGimbalControlMode,
+		67108871:  DJIGimbalResetPositionState,
+		67108872:  DJIGimbalCalibration,
+		67108873:  DJIGimbalSpeedRotation,
+		67108874:  DJIGimbalSpeedRotationEnabled,
 		67108875:  DJIGimbalAngleIncrementRotation,
 		67108876:  DJIGimbalAngleFrontYawRotation,
 		67108870:  DJIGimbalResetPosition,
 		67108877:  DJIGimbalAngleFrontPitchRotation,
+		67108878:  DJIGimbalAttitude,
+		67108879:  DJIGimbalAutoCalibrate,
+		67108880:  DJIGimbalCalibrationStatus,
+		67108881:  DJIGimbalCalibrationProgress,
 		67108882:  DJIGimbalOpenAttitudeUpdates,
+		67108883:  DJIGimbalCloseAttitudeUpdates,
 		83886081:  DJIRobomasterSystemConnection,
+		83886082:  DJIRobomasterSystemFirmwareVersion,
+		83886085:  DJIRobomasterSystemSerialNumber,
+		83886141:  DJIRobomasterSystemChassisSpeedLevel,
 		117440513: DJIAirLinkConnection,
 		// TODO(bga): Add any keys we need here.
 	}
@@ -49,6 +49,23 @@ func (r *DJIResult) Succeeded() bool {
 	return r.errorCode == 0
 }
 
+// DecodeValue decodes the result value into the given pointer to a param
+// value struct (for example, a *DJIGimbalAngleRotationParamValue). Returns a
+// nil error on success and a non-nil error on failure.
+func (r *DJIResult) DecodeValue(v any) error {
+	data, err := json.Marshal(r.value)
+	if err != nil {
+		return fmt.Errorf("error encoding value: %w", err)
+	}
+
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("error decoding value: %w", err)
+	}
+
+	return nil
+}
+
 func (r *DJIResult) parseJSONData(jsonData []byte) {
 	if len(jsonData) == 0 {
 		r.errorCode = -1
@@ -0,0 +1,11 @@
+package dji
+
+type DJIStringParamValue struct {
+	Value string `json:"value"`
+}
+
+func NewDJIStringParamValue(value string) *DJIStringParamValue {
+	return &DJIStringParamValue{
+		Value: value,
+	}
+}
@@ -0,0 +1,8 @@
+package dji
+
+type DJIWheelSpeedParamValue struct {
+	FrontRight int16 `json:"wheel1"`
+	FrontLeft  int16 `json:"wheel2"`
+	RearLeft   int16 `json:"wheel3"`
+	RearRight  int16 `json:"wheel4"`
+}
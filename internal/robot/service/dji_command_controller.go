@@ -37,6 +37,9 @@ type DJICommandController interface {
 
 	StartListeningOnKey(key dji.DJIKeys, listener interface{},
 		callback func(*dji.DJIResult), fetchFromCache bool)
+	StopListeningOnKey(key dji.DJIKeys, listener interface{})
+	SetValueForKey(key dji.DJIKeys, paramValue dji.DJIParamValue,
+		callback func(*dji.DJIResult))
 	SetValueForKeyWithNumber(key dji.DJIKeys,
 		value int64, callback func(*dji.DJIResult))
 	PerformAction(key dji.DJIKeys, callback func(*dji.DJIResult))
@@ -55,6 +58,17 @@ func DJICommandControllerInstance() DJICommandController {
 	return mInstance
 }
 
+// SetDJICommandControllerInstance replaces the DJICommandController returned
+// by DJICommandControllerInstance and returns the previous one. Meant for tests
+// that must not talk to an actual robot.
+func SetDJICommandControllerInstance(
+	cc DJICommandController) DJICommandController {
+	previous := mInstance
+	mInstance = cc
+
+	return previous
+}
+
 func (d *djiCommandController) Init() {
 	unitybridge.DJIUnityBridgeInstance().RegisterEventHandler(
 		d, unitybridge.GetValue)
@@ -154,7 +168,7 @@ func (d *djiCommandController) StopListeningOnKey(key dji.DJIKeys,
 		unitybridge.DJIUnityBridgeInstance().SendEventWithoutDataOrTag(mStopListeningEvent)
 	}
 
-	if len(d.listenersByKeyAndName) == 0 {
+	if len(listenersByName) == 0 {
 		delete(d.listenersByKeyAndName, key)
 	}
 }
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/support"
+)
+
+// DecodeResult decodes the value in the given result (usually received by a
+// key listener) into the given value. Failed results and values that can not
+// be decoded are logged as warnings. Returns true on success and false on
+// failure.
+func DecodeResult(logger *support.Logger, result *dji.DJIResult,
+	value any) bool {
+	if !result.Succeeded() {
+		logger.WARNING("Error for key %s: %s", result.Key(),
+			result.ErrorDesc())
+		return false
+	}
+
+	err := result.DecodeValue(value)
+	if err != nil {
+		logger.WARNING("Error for key %s: %s", result.Key(), err.Error())
+		return false
+	}
+
+	return true
+}
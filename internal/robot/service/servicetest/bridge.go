@@ -0,0 +1,104 @@
+package servicetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/unitybridge"
+)
+
+// Bridge is an in-memory unitybridge.DJIUnityBridge that records the events
+// sent through it and otherwise does nothing.
+type Bridge struct {
+	m      sync.Mutex
+	events []unitybridge.DJIUnityEventType
+}
+
+var _ unitybridge.DJIUnityBridge = (*Bridge)(nil)
+
+// InstallBridge makes a new Bridge the one returned by
+// unitybridge.DJIUnityBridgeInstance until the given test finishes. Returns
+// the installed Bridge.
+func InstallBridge(t testing.TB) *Bridge {
+	b := &Bridge{}
+
+	previous := unitybridge.SetDJIUnityBridgeInstance(b)
+	t.Cleanup(func() {
+		unitybridge.SetDJIUnityBridgeInstance(previous)
+	})
+
+	return b
+}
+
+// Events returns the types of all events sent so far, in order.
+func (b *Bridge) Events() []unitybridge.DJIUnityEventType {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return append([]unitybridge.DJIUnityEventType(nil), b.events...)
+}
+
+// Count returns how many events of the given type were sent so far.
+func (b *Bridge) Count(typ unitybridge.DJIUnityEventType) int {
+	count := 0
+	for _, event := range b.Events() {
+		if event == typ {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (b *Bridge) Init() {}
+
+func (b *Bridge) UnInit() {}
+
+func (b *Bridge) RegisterEventHandler(handler unitybridge.IEventHandler,
+	typ unitybridge.DJIUnityEventType) {
+}
+
+func (b *Bridge) UnregisterEventHandler(handler unitybridge.IEventHandler) {}
+
+func (b *Bridge) SendEvent(e *unitybridge.DJIUnityEvent, data []byte,
+	tag uint64) {
+	b.record(e)
+}
+
+func (b *Bridge) SendEventWithoutTag(e *unitybridge.DJIUnityEvent,
+	data []byte) {
+	b.record(e)
+}
+
+func (b *Bridge) SendEventWithoutDataOrTag(e *unitybridge.DJIUnityEvent) {
+	b.record(e)
+}
+
+func (b *Bridge) SendEventWithNumber(e *unitybridge.DJIUnityEvent,
+	data uint64, tag uint64) {
+	b.record(e)
+}
+
+func (b *Bridge) SendEventWithString(e *unitybridge.DJIUnityEvent,
+	data string, tag uint64) {
+	b.record(e)
+}
+
+func (b *Bridge) GetStringValueWithEvent(e *unitybridge.DJIUnityEvent) string {
+	return ""
+}
+
+func (b *Bridge) GetInt32ValueWithEvent(e *unitybridge.DJIUnityEvent) int32 {
+	return 0
+}
+
+func (b *Bridge) GetSecurityKeyByKeyChainIndex(index int) string {
+	return ""
+}
+
+func (b *Bridge) record(e *unitybridge.DJIUnityEvent) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.events = append(b.events, e.Type())
+}
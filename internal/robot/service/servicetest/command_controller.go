@@ -0,0 +1,286 @@
+// Package servicetest provides a fake service.DJICommandController for tests
+// that must not talk to an actual robot.
+package servicetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/robot/service/unitybridge"
+)
+
+// DirectSend is a value sent with DirectSendValue.
+type DirectSend struct {
+	Key   dji.DJIKeys
+	Value int64
+}
+
+// CommandController is an in-memory service.DJICommandController. Values set
+// for keys are returned by later gets, listeners are only called by Publish
+// (or when they ask for cached values) and actions succeed unless an error is
+// set for their keys. All callbacks are called synchronously.
+type CommandController struct {
+	m           sync.Mutex
+	values      map[dji.DJIKeys]any
+	errorCodes  map[dji.DJIKeys]int64
+	listeners   map[dji.DJIKeys]map[interface{}]func(*dji.DJIResult)
+	actions     []dji.DJIKeys
+	actionHooks map[dji.DJIKeys]func(dji.DJIParamValue)
+	directSends []DirectSend
+}
+
+var _ service.DJICommandController = (*CommandController)(nil)
+
+// New returns a new CommandController with no values set.
+func New() *CommandController {
+	return &CommandController{
+		values:      make(map[dji.DJIKeys]any),
+		errorCodes:  make(map[dji.DJIKeys]int64),
+		listeners:   make(map[dji.DJIKeys]map[interface{}]func(*dji.DJIResult)),
+		actionHooks: make(map[dji.DJIKeys]func(dji.DJIParamValue)),
+	}
+}
+
+// Install makes a new CommandController the one returned by
+// service.DJICommandControllerInstance until the given test finishes. Returns
+// the installed CommandController.
+func Install(t testing.TB) *CommandController {
+	cc := New()
+
+	previous := service.SetDJICommandControllerInstance(cc)
+	t.Cleanup(func() {
+		service.SetDJICommandControllerInstance(previous)
+	})
+
+	return cc
+}
+
+// SetValue sets the value returned for the given key.
+func (c *CommandController) SetValue(key dji.DJIKeys, value any) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.values[key] = value
+}
+
+// Value returns the value for the given key and true if there is one and nil
+// and false otherwise. Values set through the controller are returned as
+// decoded from JSON (for example, numbers are float64).
+func (c *CommandController) Value(key dji.DJIKeys) (any, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	value, ok := c.values[key]
+
+	return value, ok
+}
+
+// SetErrorCode makes all operations on the given key fail with the given
+// error code. A zero error code makes them succeed again.
+func (c *CommandController) SetErrorCode(key dji.DJIKeys, errorCode int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if errorCode == 0 {
+		delete(c.errorCodes, key)
+	} else {
+		c.errorCodes[key] = errorCode
+	}
+}
+
+// Publish sets the value for the given key and calls all listeners for it.
+func (c *CommandController) Publish(key dji.DJIKeys, value any) {
+	c.m.Lock()
+	c.values[key] = value
+	callbacks := make([]func(*dji.DJIResult), 0, len(c.listeners[key]))
+	for _, callback := range c.listeners[key] {
+		callbacks = append(callbacks, callback)
+	}
+	c.m.Unlock()
+
+	for _, callback := range callbacks {
+		callback(newResult(key, 0, value))
+	}
+}
+
+// Listeners returns the number of listeners for the given key.
+func (c *CommandController) Listeners(key dji.DJIKeys) int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return len(c.listeners[key])
+}
+
+// OnAction sets a function to be called with the action value (which might be
+// nil) whenever the action for the given key is performed successfully. It is
+// called before the action callback.
+func (c *CommandController) OnAction(key dji.DJIKeys,
+	hook func(dji.DJIParamValue)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.actionHooks[key] = hook
+}
+
+// Actions returns the keys of all actions performed so far, in order.
+func (c *CommandController) Actions() []dji.DJIKeys {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return append([]dji.DJIKeys(nil), c.actions...)
+}
+
+// DirectSends returns all values sent with DirectSendValue so far, in order.
+func (c *CommandController) DirectSends() []DirectSend {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return append([]DirectSend(nil), c.directSends...)
+}
+
+func (c *CommandController) Init() {}
+
+func (c *CommandController) UnInit() {}
+
+func (c *CommandController) OnEventCallback(
+	event *unitybridge.DJIUnityEvent, data []byte, tag uint64) {
+}
+
+func (c *CommandController) StartListeningOnKey(key dji.DJIKeys,
+	listener interface{}, callback func(*dji.DJIResult),
+	fetchFromCache bool) {
+	c.m.Lock()
+	if c.listeners[key] == nil {
+		c.listeners[key] = make(map[interface{}]func(*dji.DJIResult))
+	}
+	c.listeners[key][listener] = callback
+	value, ok := c.values[key]
+	c.m.Unlock()
+
+	if fetchFromCache && ok {
+		callback(newResult(key, 0, value))
+	}
+}
+
+func (c *CommandController) StopListeningOnKey(key dji.DJIKeys,
+	listener interface{}) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	delete(c.listeners[key], listener)
+}
+
+func (c *CommandController) SetValueForKey(key dji.DJIKeys,
+	paramValue dji.DJIParamValue, callback func(*dji.DJIResult)) {
+	c.m.Lock()
+	errorCode := c.errorCodes[key]
+	value := paramValueToValue(paramValue)
+	if errorCode == 0 {
+		c.values[key] = value
+	}
+	c.m.Unlock()
+
+	if callback != nil {
+		callback(newResult(key, errorCode, value))
+	}
+}
+
+func (c *CommandController) SetValueForKeyWithNumber(key dji.DJIKeys,
+	value int64, callback func(*dji.DJIResult)) {
+	c.SetValueForKey(key, dji.NewDJILongParamValue(value), callback)
+}
+
+func (c *CommandController) PerformAction(key dji.DJIKeys,
+	callback func(*dji.DJIResult)) {
+	c.PerformActionWithParam(key, nil, callback)
+}
+
+func (c *CommandController) PerformActionWithParam(key dji.DJIKeys,
+	value dji.DJIParamValue, callback func(*dji.DJIResult)) {
+	c.m.Lock()
+	c.actions = append(c.actions, key)
+	errorCode := c.errorCodes[key]
+	hook := c.actionHooks[key]
+	c.m.Unlock()
+
+	if hook != nil && errorCode == 0 {
+		hook(value)
+	}
+
+	if callback != nil {
+		callback(newResult(key, errorCode, true))
+	}
+}
+
+func (c *CommandController) DirectSendValue(key dji.DJIKeys, value int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.directSends = append(c.directSends, DirectSend{key, value})
+}
+
+func (c *CommandController) GetValueForKey(key dji.DJIKeys,
+	callback func(*dji.DJIResult)) {
+	c.m.Lock()
+	errorCode := c.errorCodes[key]
+	value, ok := c.values[key]
+	c.m.Unlock()
+
+	if errorCode == 0 && !ok {
+		// What the robot reports for values it does not have.
+		errorCode = -1
+	}
+
+	callback(newResult(key, errorCode, value))
+}
+
+// paramValueToValue returns the value the robot would report for the given
+// param value. Param values with a single value field are reported as that
+// value.
+func paramValueToValue(paramValue dji.DJIParamValue) any {
+	data, err := json.Marshal(paramValue)
+	if err != nil {
+		panic(fmt.Sprintf("invalid param value %#v: %s", paramValue, err))
+	}
+
+	var value any
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		panic(fmt.Sprintf("invalid param value %#v: %s", paramValue, err))
+	}
+
+	if fields, ok := value.(map[string]any); ok && len(fields) == 1 {
+		if inner, ok := fields["value"]; ok {
+			return inner
+		}
+	}
+
+	return value
+}
+
+// newResult returns a result as the robot would report it for the given key,
+// error code and value.
+func newResult(key dji.DJIKeys, errorCode int64, value any) *dji.DJIResult {
+	if errorCode != 0 {
+		// Results with nil values are parsed with a -1 error code, so use a
+		// placeholder value to keep the given one.
+		value = false
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"Tag":   0,
+		"Key":   key.Value(),
+		"Error": errorCode,
+		"Value": map[string]any{"value": value},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("invalid value %#v for key %s: %s", value, key,
+			err))
+	}
+
+	return dji.NewDJIResultFromJSON(data)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+// GetValueForKeySync gets the value for the given key and waits for the
+// result. Returns the result and a nil error on success and nil and a non-nil
+// error on failure (including the context being done before the result
+// arrives).
+func GetValueForKeySync(ctx context.Context, cc DJICommandController,
+	key dji.DJIKeys) (*dji.DJIResult, error) {
+	resultChan := make(chan *dji.DJIResult, 1)
+
+	cc.GetValueForKey(key, func(result *dji.DJIResult) {
+		resultChan <- result
+	})
+
+	return waitForResult(ctx, key, resultChan)
+}
+
+// SetValueForKeySync sets the value for the given key and waits for the
+// result. Returns a nil error on success and a non-nil error on failure
+// (including the context being done before the result arrives).
+func SetValueForKeySync(ctx context.Context, cc DJICommandController,
+	key dji.DJIKeys, value dji.DJIParamValue) error {
+	resultChan := make(chan *dji.DJIResult, 1)
+
+	cc.SetValueForKey(key, value, func(result *dji.DJIResult) {
+		resultChan <- result
+	})
+
+	_, err := waitForResult(ctx, key, resultChan)
+
+	return err
+}
+
+// GetNumberForKeySync gets the integer value for the given key and waits for
+// the result. Returns the value and a nil error on success and 0 and a non-nil
+// error on failure (including the context being done before the result
+// arrives).
+func GetNumberForKeySync(ctx context.Context, cc DJICommandController,
+	key dji.DJIKeys) (int64, error) {
+	result, err := GetValueForKeySync(ctx, cc, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var value int64
+	err = result.DecodeValue(&value)
+	if err != nil {
+		return 0, fmt.Errorf("error for key %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// SetNumberForKeySync sets the given integer value for the given key and waits
+// for the result. Returns a nil error on success and a non-nil error on
+// failure (including the context being done before the result arrives).
+func SetNumberForKeySync(ctx context.Context, cc DJICommandController,
+	key dji.DJIKeys, value int64) error {
+	return SetValueForKeySync(ctx, cc, key, dji.NewDJILongParamValue(value))
+}
+
+// PerformActionSync performs the action for the given key with the given
+// value (which might be nil) and waits for the result. Returns the result and
+// a nil error on success and nil and a non-nil error on failure (including the
+// context being done before the result arrives).
+func PerformActionSync(ctx context.Context, cc DJICommandController,
+	key dji.DJIKeys, value dji.DJIParamValue) (*dji.DJIResult, error) {
+	resultChan := make(chan *dji.DJIResult, 1)
+
+	cc.PerformActionWithParam(key, value, func(result *dji.DJIResult) {
+		resultChan <- result
+	})
+
+	return waitForResult(ctx, key, resultChan)
+}
+
+func waitForResult(ctx context.Context, key dji.DJIKeys,
+	resultChan <-chan *dji.DJIResult) (*dji.DJIResult, error) {
+	select {
+	case result := <-resultChan:
+		if !result.Succeeded() {
+			return nil, fmt.Errorf("error for key %s: %d (%s)", key,
+				result.ErrorCode(), result.ErrorDesc())
+		}
+
+		return result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("error waiting for key %s: %w", key,
+			ctx.Err())
+	}
+}
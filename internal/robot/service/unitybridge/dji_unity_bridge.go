@@ -19,6 +19,16 @@ func DJIUnityBridgeInstance() DJIUnityBridge {
 	return mInstance
 }
 
+// SetDJIUnityBridgeInstance replaces the DJIUnityBridge returned by
+// DJIUnityBridgeInstance and returns the previous one. Meant for tests that
+// must not talk to an actual robot.
+func SetDJIUnityBridgeInstance(b DJIUnityBridge) DJIUnityBridge {
+	previous := mInstance
+	mInstance = b
+
+	return previous
+}
+
 type DJIUnityBridge interface {
 	Init()
 	UnInit()
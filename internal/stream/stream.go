@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// Stream fans out values of type T to any number of subscribers. Publishing
+// never blocks: subscribers that are not keeping up only get the most recent
+// value.
+type Stream[T any] struct {
+	m           sync.Mutex
+	subscribers map[chan T]struct{}
+	latest      T
+	hasLatest   bool
+}
+
+// New returns a new Stream instance with no subscribers.
+func New[T any]() *Stream[T] {
+	return &Stream[T]{
+		subscribers: make(map[chan T]struct{}),
+	}
+}
+
+// Subscribe returns a channel where all values published from now on are
+// delivered. The channel is closed when the given context is done.
+func (s *Stream[T]) Subscribe(ctx context.Context) <-chan T {
+	ch := make(chan T, 1)
+
+	s.m.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.m.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.m.Lock()
+		delete(s.subscribers, ch)
+		close(ch)
+		s.m.Unlock()
+	}()
+
+	return ch
+}
+
+// Publish delivers the given value to all subscribers. If a subscriber still
+// did not receive the previous value, it is replaced by the given one.
+func (s *Stream[T]) Publish(value T) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.latest = value
+	s.hasLatest = true
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- value:
+		default:
+			// Drop the stale value and deliver the new one instead.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- value
+		}
+	}
+}
+
+// Latest returns the last value published and true or the zero value and false
+// if nothing was published yet.
+func (s *Stream[T]) Latest() (T, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.latest, s.hasLatest
+}
+
+// Reset forgets the last value published.
+func (s *Stream[T]) Reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var zero T
+	s.latest = zero
+	s.hasLatest = false
+}
@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStream_Latest(t *testing.T) {
+	s := New[int]()
+
+	_, ok := s.Latest()
+	if ok {
+		t.Fatalf("expected no latest value")
+	}
+
+	s.Publish(1)
+	s.Publish(2)
+
+	value, ok := s.Latest()
+	if !ok || value != 2 {
+		t.Fatalf("expected latest value 2, got %d (%t)", value, ok)
+	}
+
+	s.Reset()
+
+	_, ok = s.Latest()
+	if ok {
+		t.Fatalf("expected no latest value after reset")
+	}
+}
+
+func TestStream_Subscribe_Success(t *testing.T) {
+	s := New[int]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch1 := s.Subscribe(ctx)
+	ch2 := s.Subscribe(ctx)
+
+	s.Publish(1)
+
+	if value := <-ch1; value != 1 {
+		t.Fatalf("expected 1, got %d", value)
+	}
+	if value := <-ch2; value != 1 {
+		t.Fatalf("expected 1, got %d", value)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch1:
+		if ok {
+			t.Fatalf("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected channel to be closed")
+	}
+}
+
+func TestStream_Subscribe_SlowSubscriber(t *testing.T) {
+	s := New[int]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := s.Subscribe(ctx)
+
+	for i := 1; i <= 10; i++ {
+		s.Publish(i)
+	}
+
+	if value := <-ch; value != 10 {
+		t.Fatalf("expected most recent value 10, got %d", value)
+	}
+}
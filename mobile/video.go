@@ -26,6 +26,6 @@ type handler struct {
 	videoHandler VideoHandler
 }
 
-func (vh *handler) HandleVideo(img *video.RGB) {
-	vh.videoHandler.HandleVideo(img.Pix)
+func (vh *handler) HandleVideo(frame *video.Frame) {
+	vh.videoHandler.HandleVideo(frame.Pix)
 }
@@ -1,21 +1,55 @@
 package chassis
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/brunoga/robomaster2/internal/robot/service"
 	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/stream"
+	"github.com/brunoga/robomaster2/modules/chassis/kinematics"
+	"github.com/brunoga/robomaster2/modules/robot"
 	"github.com/brunoga/robomaster2/support"
 )
 
 type Chassis struct {
 	logger *support.Logger
+	robot  *robot.Robot
+
+	geometry kinematics.Geometry
+
+	m        sync.Mutex
+	limits   SpeedLimits
+	velocity Velocity
+
+	speedStream            *stream.Stream[SpeedUpdate]
+	positionStream         *stream.Stream[PositionUpdate]
+	relativePositionStream *stream.Stream[PositionUpdate]
+	wheelSpeedStream       *stream.Stream[WheelSpeedUpdate]
+	odometry               *Odometry
+
+	modeStream *stream.Stream[ModeUpdate]
+
+	configM             sync.Mutex
+	originalSpeedConfig *SpeedConfig
 }
 
-func New(logger *support.Logger) *Chassis {
+// New returns a new Chassis instance that drives the chassis through the
+// virtual sticks of the given robot.
+func New(logger *support.Logger, r *robot.Robot) *Chassis {
 	return &Chassis{
-		logger,
+		logger:                 logger,
+		robot:                  r,
+		geometry:               kinematics.S1,
+		limits:                 defaultSpeedLimits,
+		speedStream:            stream.New[SpeedUpdate](),
+		positionStream:         stream.New[PositionUpdate](),
+		relativePositionStream: stream.New[PositionUpdate](),
+		wheelSpeedStream:       stream.New[WheelSpeedUpdate](),
+		odometry:               NewOdometry(),
+		modeStream:             stream.New[ModeUpdate](),
 	}
 }
 
@@ -23,6 +57,7 @@ func (c *Chassis) Start() {
 	cc := service.DJICommandControllerInstance()
 
 	connectionWg := sync.WaitGroup{}
+	connectionOnce := sync.Once{}
 
 	connectionWg.Add(1)
 	cc.StartListeningOnKey(dji.DJIRobomasterSystemConnection, c,
@@ -34,8 +69,70 @@ func (c *Chassis) Start() {
 				fmt.Println("Chassis connection failed.")
 			}
 
-			connectionWg.Done()
+			// Connection updates keep coming after the first one.
+			connectionOnce.Do(connectionWg.Done)
 		}, false)
 
 	connectionWg.Wait()
+
+	c.updateSpeedLimits()
+	c.startTelemetry()
+	c.startModeUpdates()
+}
+
+// Stop brakes the chassis (see robot.Robot.CenterSticks) and stops chassis
+// telemetry and mode updates. Any speed configuration changes are reverted.
+// The robot keeps sending virtual stick commands for its other users.
+func (c *Chassis) Stop() {
+	c.stopModeUpdates()
+	c.stopTelemetry()
+
+	c.m.Lock()
+	c.velocity = Velocity{}
+	c.m.Unlock()
+
+	// The robot is shared (for example, with the gimbal), so only center the
+	// sticks instead of stopping it.
+	c.robot.CenterSticks()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := c.RestoreSpeedConfig(ctx)
+	if err != nil {
+		c.logger.ERROR("Error restoring speed config: %s", err.Error())
+	}
+}
+
+// updateSpeedLimits reads the current maximum speeds from the robot. Defaults
+// are kept for any speed that can not be read.
+func (c *Chassis) updateSpeedLimits() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cc := service.DJICommandControllerInstance()
+
+	c.m.Lock()
+	limits := c.limits
+	c.m.Unlock()
+
+	for key, value := range map[dji.DJIKeys]*float64{
+		dji.DJIMainControllerMaxSpeedForward:  &limits.Forward,
+		dji.DJIMainControllerMaxSpeedBackward: &limits.Backward,
+		dji.DJIMainControllerMaxSpeedLateral:  &limits.Lateral,
+	} {
+		result, err := service.GetValueForKeySync(ctx, cc, key)
+		if err != nil {
+			c.logger.WARNING("Could not read %s: %s", key, err.Error())
+			continue
+		}
+
+		if speed, ok := result.Value().(float64); ok && speed > 0 {
+			*value = speed
+		}
+	}
+
+	c.m.Lock()
+	c.limits = limits
+	c.m.Unlock()
 }
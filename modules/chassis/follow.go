@@ -0,0 +1,290 @@
+package chassis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/stream"
+)
+
+var (
+	// ErrAborted is returned by Follower.Wait when following was aborted.
+	ErrAborted = errors.New("trajectory following aborted")
+)
+
+// FollowOptions controls how a Trajectory is followed.
+type FollowOptions struct {
+	// Proportional gains used to correct the position (1/s) and heading
+	// (1/s) errors relative to the trajectory.
+	Kp         float64
+	KpRotation float64
+
+	// Maximum translation speed (m/s) and rotation speed (degrees/s).
+	MaxSpeed         float64
+	MaxRotationSpeed float64
+
+	// Following is done when the trajectory time is over and the chassis is
+	// within these distance (m) and angle (degrees) from the final pose.
+	PositionTolerance float64
+	YawTolerance      float64
+
+	// Maximum time to reach the final pose after the trajectory time is over.
+	SettleTime time.Duration
+}
+
+// DefaultFollowOptions returns reasonable options for following trajectories.
+func DefaultFollowOptions() FollowOptions {
+	return FollowOptions{
+		Kp:                1.5,
+		KpRotation:        2,
+		MaxSpeed:          1.5,
+		MaxRotationSpeed:  180,
+		PositionTolerance: 0.05,
+		YawTolerance:      5,
+		SettleTime:        3 * time.Second,
+	}
+}
+
+// FollowProgress reports how trajectory following is going.
+type FollowProgress struct {
+	Time time.Time
+
+	// Trajectory time reached so far (it does not advance while paused) and
+	// the fraction of the trajectory duration it represents.
+	Elapsed  time.Duration
+	Fraction float64
+
+	// Index of the trajectory segment being followed.
+	Segment int
+
+	// Pose the chassis should be at and the pose it actually is at, both
+	// relative to the pose it had when following started.
+	Reference Pose
+	Pose      Pose
+
+	// Distance (m) from the chassis to the segment being followed. Positive
+	// when to the right of it.
+	CrossTrackError float64
+
+	Paused bool
+}
+
+// Follower controls the following of a trajectory started with Follow.
+type Follower struct {
+	chassis    *Chassis
+	trajectory Trajectory
+	opts       FollowOptions
+	cancel     context.CancelFunc
+
+	progressStream *stream.Stream[FollowProgress]
+
+	m       sync.Mutex
+	paused  bool
+	aborted bool
+
+	doneChan chan struct{}
+	err      error
+}
+
+// Follow starts driving the chassis along the given trajectory, relative to
+// its current pose, using position updates as feedback. Following happens in
+// the background until the trajectory is completed, it is aborted or the
+// given context is done. Returns a Follower to control it and a nil error on
+// success and nil and a non-nil error on failure.
+func (c *Chassis) Follow(ctx context.Context, trajectory Trajectory,
+	opts FollowOptions) (*Follower, error) {
+	if len(trajectory.points) == 0 {
+		return nil, fmt.Errorf("empty trajectory")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	f := &Follower{
+		chassis:        c,
+		trajectory:     trajectory,
+		opts:           opts,
+		cancel:         cancel,
+		progressStream: stream.New[FollowProgress](),
+		doneChan:       make(chan struct{}),
+	}
+
+	go f.loop(ctx)
+
+	return f, nil
+}
+
+// SubscribeProgress returns a channel where progress updates are delivered
+// until the given context is done.
+func (f *Follower) SubscribeProgress(ctx context.Context) <-chan FollowProgress {
+	return f.progressStream.Subscribe(ctx)
+}
+
+// Progress returns the latest progress update. Returns the update and true if
+// there is one and an empty FollowProgress and false otherwise.
+func (f *Follower) Progress() (FollowProgress, bool) {
+	return f.progressStream.Latest()
+}
+
+// Pause stops the chassis and the trajectory time until Resume is called.
+func (f *Follower) Pause() {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.paused = true
+}
+
+// Resume continues following the trajectory after Pause.
+func (f *Follower) Resume() {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.paused = false
+}
+
+// Abort stops following the trajectory. The chassis is braked.
+func (f *Follower) Abort() {
+	f.m.Lock()
+	f.aborted = true
+	f.m.Unlock()
+
+	f.cancel()
+}
+
+// Done returns a channel that is closed when following finishes.
+func (f *Follower) Done() <-chan struct{} {
+	return f.doneChan
+}
+
+// Wait waits for following to finish. Returns a nil error if the trajectory
+// was completed, ErrAborted if it was aborted, ErrNoFeedback if position
+// updates stopped, ErrMoveTimeout if the final pose was not reached in time
+// and a non-nil error if the context passed to Follow is done.
+func (f *Follower) Wait() error {
+	<-f.doneChan
+
+	return f.err
+}
+
+func (f *Follower) loop(ctx context.Context) {
+	defer close(f.doneChan)
+	defer f.cancel()
+
+	f.err = f.follow(ctx)
+}
+
+func (f *Follower) follow(ctx context.Context) error {
+	c := f.chassis
+
+	defer c.Brake()
+
+	start, lastUpdate := c.odometry.Pose()
+
+	ticker := time.NewTicker(moveControlInterval)
+	defer ticker.Stop()
+
+	var elapsed time.Duration
+
+	duration := f.trajectory.Duration()
+	lastTick := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.m.Lock()
+			aborted := f.aborted
+			f.m.Unlock()
+
+			if aborted {
+				return ErrAborted
+			}
+
+			return fmt.Errorf("error following trajectory: %w", ctx.Err())
+		case now := <-ticker.C:
+			odometryPose, updated := c.odometry.Pose()
+			if updated.After(lastUpdate) {
+				lastUpdate = updated
+			} else if now.Sub(lastUpdate) > moveFeedbackTimeout {
+				return ErrNoFeedback
+			}
+
+			f.m.Lock()
+			paused := f.paused
+			f.m.Unlock()
+
+			if !paused {
+				elapsed += now.Sub(lastTick)
+			}
+			lastTick = now
+
+			pose := relativePose(start, odometryPose)
+			reference, velocity, segment := f.trajectory.Sample(elapsed)
+
+			fraction := 1.0
+			if duration > 0 {
+				fraction = math.Min(1, float64(elapsed)/float64(duration))
+			}
+
+			f.progressStream.Publish(FollowProgress{
+				Time:            now,
+				Elapsed:         elapsed,
+				Fraction:        fraction,
+				Segment:         segment,
+				Reference:       reference,
+				Pose:            pose,
+				CrossTrackError: f.trajectory.crossTrackError(pose, segment),
+				Paused:          paused,
+			})
+
+			if paused {
+				c.Brake()
+				continue
+			}
+
+			if elapsed >= duration {
+				errPose := relativePose(pose, reference)
+				if math.Hypot(errPose.X, errPose.Y) <= f.opts.PositionTolerance &&
+					math.Abs(errPose.Yaw) <= f.opts.YawTolerance {
+					return nil
+				}
+
+				if elapsed >= duration+f.opts.SettleTime {
+					return ErrMoveTimeout
+				}
+			}
+
+			command := f.command(pose, reference, velocity)
+			c.SetVelocity(command.Vx, command.Vy, command.Wz)
+		}
+	}
+}
+
+// command returns the body velocity needed to follow the reference pose and
+// velocity (in the trajectory frame) given the current pose.
+func (f *Follower) command(pose, reference Pose, velocity Velocity) Velocity {
+	// Feed forward the trajectory velocity and correct the errors.
+	vx := velocity.Vx + f.opts.Kp*(reference.X-pose.X)
+	vy := velocity.Vy + f.opts.Kp*(reference.Y-pose.Y)
+	wz := velocity.Wz +
+		f.opts.KpRotation*normalizeAngle(reference.Yaw-pose.Yaw)
+
+	if speed := math.Hypot(vx, vy); speed > f.opts.MaxSpeed {
+		vx *= f.opts.MaxSpeed / speed
+		vy *= f.opts.MaxSpeed / speed
+	}
+
+	wz = math.Max(-f.opts.MaxRotationSpeed,
+		math.Min(f.opts.MaxRotationSpeed, wz))
+
+	// Rotate to the body frame.
+	sin, cos := math.Sincos(pose.Yaw * math.Pi / 180)
+
+	return Velocity{
+		Vx: vx*cos + vy*sin,
+		Vy: -vx*sin + vy*cos,
+		Wz: wz,
+	}
+}
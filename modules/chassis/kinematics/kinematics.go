@@ -0,0 +1,153 @@
+// Package kinematics provides forward and inverse kinematics for the mecanum
+// wheel chassis used by the RoboMaster S1 and EP robots.
+//
+// All velocities use the robot body frame: Vx is the forward speed and Vy is
+// the speed to the right, both in m/s, and Wz is the clockwise rotation speed
+// in degrees/s. Wheel speeds are in RPM and are positive when the wheel rolls
+// forward.
+package kinematics
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	// S1 is the geometry of the RoboMaster S1 and EP chassis.
+	S1 = Geometry{
+		WheelRadius:   0.05,
+		TrackWidth:    0.2,
+		WheelBase:     0.2,
+		MaxWheelSpeed: 1000,
+	}
+)
+
+// Geometry describes the physical layout of a mecanum chassis.
+type Geometry struct {
+	// Wheel radius in meters.
+	WheelRadius float64
+
+	// Distance between the left and right wheel centers, in meters.
+	TrackWidth float64
+
+	// Distance between the front and rear wheel centers, in meters.
+	WheelBase float64
+
+	// Maximum wheel speed, in RPM.
+	MaxWheelSpeed float64
+}
+
+// Twist is a chassis velocity in the body frame.
+type Twist struct {
+	Vx float64
+	Vy float64
+	Wz float64
+}
+
+func (t Twist) String() string {
+	return fmt.Sprintf("Vx:%.3f m/s, Vy:%.3f m/s, Wz:%.1f deg/s", t.Vx, t.Vy,
+		t.Wz)
+}
+
+// WheelSpeeds holds the speed of each chassis wheel, in RPM.
+type WheelSpeeds struct {
+	FrontRight float64
+	FrontLeft  float64
+	RearLeft   float64
+	RearRight  float64
+}
+
+func (w WheelSpeeds) String() string {
+	return fmt.Sprintf("FrontRight:%.1f, FrontLeft:%.1f, RearLeft:%.1f, "+
+		"RearRight:%.1f RPM", w.FrontRight, w.FrontLeft, w.RearLeft,
+		w.RearRight)
+}
+
+// Max returns the largest absolute wheel speed.
+func (w WheelSpeeds) Max() float64 {
+	return math.Max(math.Max(math.Abs(w.FrontRight), math.Abs(w.FrontLeft)),
+		math.Max(math.Abs(w.RearLeft), math.Abs(w.RearRight)))
+}
+
+// Inverse returns the wheel speeds needed to move with the given twist. The
+// result is not saturated (see Saturate).
+func (g Geometry) Inverse(twist Twist) WheelSpeeds {
+	rotation := g.lever() * twist.Wz * math.Pi / 180
+
+	return WheelSpeeds{
+		FrontRight: g.toRPM(twist.Vx - twist.Vy - rotation),
+		FrontLeft:  g.toRPM(twist.Vx + twist.Vy + rotation),
+		RearLeft:   g.toRPM(twist.Vx - twist.Vy + rotation),
+		RearRight:  g.toRPM(twist.Vx + twist.Vy - rotation),
+	}
+}
+
+// Forward returns the twist resulting from the given wheel speeds. Wheel
+// speeds that are not consistent with any rigid body motion (i.e. some wheels
+// slipping) result in the least squares twist.
+func (g Geometry) Forward(wheels WheelSpeeds) Twist {
+	fr := g.fromRPM(wheels.FrontRight)
+	fl := g.fromRPM(wheels.FrontLeft)
+	rl := g.fromRPM(wheels.RearLeft)
+	rr := g.fromRPM(wheels.RearRight)
+
+	return Twist{
+		Vx: (fr + fl + rl + rr) / 4,
+		Vy: (-fr + fl - rl + rr) / 4,
+		Wz: (-fr + fl + rl - rr) / (4 * g.lever()) * 180 / math.Pi,
+	}
+}
+
+// Saturate scales down the given wheel speeds so none of them is above the
+// maximum wheel speed. All wheels are scaled by the same factor, so the
+// direction of movement is preserved.
+func (g Geometry) Saturate(wheels WheelSpeeds) WheelSpeeds {
+	scale := wheels.Max() / g.MaxWheelSpeed
+	if g.MaxWheelSpeed <= 0 || scale <= 1 {
+		return wheels
+	}
+
+	return WheelSpeeds{
+		FrontRight: wheels.FrontRight / scale,
+		FrontLeft:  wheels.FrontLeft / scale,
+		RearLeft:   wheels.RearLeft / scale,
+		RearRight:  wheels.RearRight / scale,
+	}
+}
+
+// Limit returns the given twist scaled down so it can be achieved without any
+// wheel going above the maximum wheel speed. The direction of movement is
+// preserved.
+func (g Geometry) Limit(twist Twist) Twist {
+	scale := g.Inverse(twist).Max() / g.MaxWheelSpeed
+	if g.MaxWheelSpeed <= 0 || scale <= 1 {
+		return twist
+	}
+
+	return Twist{
+		Vx: twist.Vx / scale,
+		Vy: twist.Vy / scale,
+		Wz: twist.Wz / scale,
+	}
+}
+
+// MaxSpeed returns the maximum linear speed, in m/s, a wheel can reach.
+func (g Geometry) MaxSpeed() float64 {
+	return g.fromRPM(g.MaxWheelSpeed)
+}
+
+// lever returns the rotation lever arm (half the track width plus half the
+// wheel base) in meters.
+func (g Geometry) lever() float64 {
+	return (g.TrackWidth + g.WheelBase) / 2
+}
+
+// toRPM converts a wheel rim speed in m/s to RPM.
+func (g Geometry) toRPM(speed float64) float64 {
+	return speed / g.WheelRadius * 60 / (2 * math.Pi)
+}
+
+// fromRPM converts a wheel speed in RPM to a rim speed in m/s.
+func (g Geometry) fromRPM(rpm float64) float64 {
+	return rpm * 2 * math.Pi / 60 * g.WheelRadius
+}
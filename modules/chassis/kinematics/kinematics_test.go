@@ -0,0 +1,145 @@
+package kinematics
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func TestInverse_Forward(t *testing.T) {
+	wheels := S1.Inverse(Twist{Vx: 1})
+
+	expected := 1 / S1.WheelRadius * 60 / (2 * math.Pi)
+	for _, speed := range []float64{wheels.FrontRight, wheels.FrontLeft,
+		wheels.RearLeft, wheels.RearRight} {
+		if !almostEqual(speed, expected) {
+			t.Fatalf("expected all wheels at %f RPM, got %s", expected,
+				wheels)
+		}
+	}
+}
+
+func TestInverse_Right(t *testing.T) {
+	wheels := S1.Inverse(Twist{Vy: 1})
+
+	if wheels.FrontLeft <= 0 || wheels.RearRight <= 0 {
+		t.Fatalf("expected front left and rear right wheels forward, got %s",
+			wheels)
+	}
+	if wheels.FrontRight >= 0 || wheels.RearLeft >= 0 {
+		t.Fatalf("expected front right and rear left wheels backward, got %s",
+			wheels)
+	}
+}
+
+func TestInverse_Clockwise(t *testing.T) {
+	wheels := S1.Inverse(Twist{Wz: 90})
+
+	if wheels.FrontLeft <= 0 || wheels.RearLeft <= 0 {
+		t.Fatalf("expected left wheels forward, got %s", wheels)
+	}
+	if wheels.FrontRight >= 0 || wheels.RearRight >= 0 {
+		t.Fatalf("expected right wheels backward, got %s", wheels)
+	}
+}
+
+func TestForward_RoundTrip(t *testing.T) {
+	for _, twist := range []Twist{
+		{},
+		{Vx: 1},
+		{Vy: -0.5},
+		{Wz: 180},
+		{Vx: 0.3, Vy: -0.7, Wz: -45},
+		{Vx: -2, Vy: 1.5, Wz: 300},
+	} {
+		result := S1.Forward(S1.Inverse(twist))
+		if !twistAlmostEqual(result, twist) {
+			t.Fatalf("expected %s, got %s", twist, result)
+		}
+	}
+}
+
+func TestForward_Slipping(t *testing.T) {
+	// Only one wheel moving is not a rigid body motion. The least squares
+	// twist is a quarter of each single wheel contribution.
+	twist := S1.Forward(WheelSpeeds{FrontLeft: 100})
+
+	if twist.Vx <= 0 || twist.Vy <= 0 || twist.Wz <= 0 {
+		t.Fatalf("expected forward, right and clockwise movement, got %s",
+			twist)
+	}
+}
+
+func TestSaturate(t *testing.T) {
+	wheels := WheelSpeeds{
+		FrontRight: 2000,
+		FrontLeft:  -1000,
+		RearLeft:   500,
+		RearRight:  0,
+	}
+
+	result := S1.Saturate(wheels)
+
+	if !almostEqual(result.Max(), S1.MaxWheelSpeed) {
+		t.Fatalf("expected max wheel speed %f, got %s", S1.MaxWheelSpeed,
+			result)
+	}
+
+	expected := WheelSpeeds{
+		FrontRight: 1000,
+		FrontLeft:  -500,
+		RearLeft:   250,
+		RearRight:  0,
+	}
+	if result != expected {
+		t.Fatalf("expected %s, got %s", expected, result)
+	}
+
+	// Speeds within the limit are unchanged.
+	if result := S1.Saturate(expected); result != expected {
+		t.Fatalf("expected %s, got %s", expected, result)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	twist := Twist{Vx: 10, Vy: 5, Wz: 200}
+
+	result := S1.Limit(twist)
+
+	if !almostEqual(S1.Inverse(result).Max(), S1.MaxWheelSpeed) {
+		t.Fatalf("expected max wheel speed %f, got %s", S1.MaxWheelSpeed,
+			S1.Inverse(result))
+	}
+
+	// Direction is preserved.
+	scale := result.Vx / twist.Vx
+	if !almostEqual(result.Vy, twist.Vy*scale) ||
+		!almostEqual(result.Wz, twist.Wz*scale) {
+		t.Fatalf("expected %s scaled by %f, got %s", twist, scale, result)
+	}
+
+	// Achievable twists are unchanged.
+	slow := Twist{Vx: 0.5, Vy: -0.2, Wz: 30}
+	if result := S1.Limit(slow); result != slow {
+		t.Fatalf("expected %s, got %s", slow, result)
+	}
+}
+
+func TestMaxSpeed(t *testing.T) {
+	wheels := S1.Inverse(Twist{Vx: S1.MaxSpeed()})
+
+	if !almostEqual(wheels.Max(), S1.MaxWheelSpeed) {
+		t.Fatalf("expected max wheel speed %f, got %s", S1.MaxWheelSpeed,
+			wheels)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+func twistAlmostEqual(a, b Twist) bool {
+	return almostEqual(a.Vx, b.Vx) && almostEqual(a.Vy, b.Vy) &&
+		almostEqual(a.Wz, b.Wz)
+}
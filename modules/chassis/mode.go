@@ -0,0 +1,265 @@
+package chassis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+// Mode is the relationship between chassis and gimbal movement.
+type Mode int64
+
+const (
+	// Chassis and gimbal move independently.
+	ModeFree Mode = iota
+
+	// The chassis rotates to follow the gimbal heading.
+	ModeChassisFollowsGimbal
+
+	// The gimbal rotates to follow the chassis heading.
+	ModeGimbalFollowsChassis
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeFree:
+		return "Free"
+	case ModeChassisFollowsGimbal:
+		return "ChassisFollowsGimbal"
+	case ModeGimbalFollowsChassis:
+		return "GimbalFollowsChassis"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(m))
+}
+
+// FollowMode is the main controller chassis follow mode.
+type FollowMode int64
+
+const (
+	// The chassis does not follow the gimbal.
+	FollowModeNone FollowMode = iota
+
+	// The chassis follows the gimbal.
+	FollowModeGimbal
+)
+
+func (f FollowMode) String() string {
+	switch f {
+	case FollowModeNone:
+		return "None"
+	case FollowModeGimbal:
+		return "Gimbal"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(f))
+}
+
+// SpeedMode is the main controller chassis speed mode.
+type SpeedMode int64
+
+const (
+	SpeedModeNormal SpeedMode = iota
+	SpeedModeFast
+)
+
+func (s SpeedMode) String() string {
+	switch s {
+	case SpeedModeNormal:
+		return "Normal"
+	case SpeedModeFast:
+		return "Fast"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(s))
+}
+
+// CarControlMode is how stick input is mapped to chassis movement.
+type CarControlMode int64
+
+const (
+	// Lateral input moves the chassis sideways (mecanum movement).
+	CarControlModeMecanum CarControlMode = iota
+
+	// Lateral input steers the chassis, like a car.
+	CarControlModeCar
+)
+
+func (c CarControlMode) String() string {
+	switch c {
+	case CarControlModeMecanum:
+		return "Mecanum"
+	case CarControlModeCar:
+		return "Car"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(c))
+}
+
+// SpeedLevel is the system wide chassis speed level.
+type SpeedLevel int64
+
+const (
+	SpeedLevelLow SpeedLevel = iota
+	SpeedLevelMedium
+	SpeedLevelHigh
+)
+
+func (s SpeedLevel) String() string {
+	switch s {
+	case SpeedLevelLow:
+		return "Low"
+	case SpeedLevelMedium:
+		return "Medium"
+	case SpeedLevelHigh:
+		return "High"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(s))
+}
+
+// ModeUpdate is a chassis Mode change.
+type ModeUpdate struct {
+	Time time.Time
+	Mode Mode
+}
+
+// Mode returns the current chassis Mode. Returns the mode and a nil error on
+// success and ModeFree and a non-nil error on failure.
+func (c *Chassis) Mode(ctx context.Context) (Mode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIRobomasterChassisMode)
+
+	return Mode(value), err
+}
+
+// SetMode sets the chassis Mode. Subscribers (see SubscribeMode) are notified
+// if the mode changed. Returns a nil error on success and a non-nil error on
+// failure.
+func (c *Chassis) SetMode(ctx context.Context, mode Mode) error {
+	err := service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIRobomasterChassisMode,
+		int64(mode))
+	if err != nil {
+		return err
+	}
+
+	c.publishMode(mode)
+
+	return nil
+}
+
+// SubscribeMode returns a channel where chassis Mode changes are delivered
+// until the given context is done.
+func (c *Chassis) SubscribeMode(ctx context.Context) <-chan ModeUpdate {
+	return c.modeStream.Subscribe(ctx)
+}
+
+// FollowMode returns the current chassis FollowMode. Returns the mode and a
+// nil error on success and FollowModeNone and a non-nil error on failure.
+func (c *Chassis) FollowMode(ctx context.Context) (FollowMode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisFollowMode)
+
+	return FollowMode(value), err
+}
+
+// SetFollowMode sets the chassis FollowMode. Returns a nil error on success
+// and a non-nil error on failure.
+func (c *Chassis) SetFollowMode(ctx context.Context, mode FollowMode) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisFollowMode, int64(mode))
+}
+
+// SpeedMode returns the current chassis SpeedMode. Returns the mode and a nil
+// error on success and SpeedModeNormal and a non-nil error on failure.
+func (c *Chassis) SpeedMode(ctx context.Context) (SpeedMode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisSpeedMode)
+
+	return SpeedMode(value), err
+}
+
+// SetSpeedMode sets the chassis SpeedMode. Returns a nil error on success and
+// a non-nil error on failure.
+func (c *Chassis) SetSpeedMode(ctx context.Context, mode SpeedMode) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisSpeedMode, int64(mode))
+}
+
+// CarControlMode returns the current chassis CarControlMode. Returns the mode
+// and a nil error on success and CarControlModeMecanum and a non-nil error on
+// failure.
+func (c *Chassis) CarControlMode(ctx context.Context) (CarControlMode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisCarControlMode)
+
+	return CarControlMode(value), err
+}
+
+// SetCarControlMode sets the chassis CarControlMode. Returns a nil error on
+// success and a non-nil error on failure.
+func (c *Chassis) SetCarControlMode(ctx context.Context,
+	mode CarControlMode) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIMainControllerChassisCarControlMode, int64(mode))
+}
+
+// SpeedLevel returns the current chassis SpeedLevel. Returns the level and a
+// nil error on success and SpeedLevelLow and a non-nil error on failure.
+func (c *Chassis) SpeedLevel(ctx context.Context) (SpeedLevel, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIRobomasterSystemChassisSpeedLevel)
+
+	return SpeedLevel(value), err
+}
+
+// SetSpeedLevel sets the chassis SpeedLevel. Returns a nil error on success
+// and a non-nil error on failure.
+func (c *Chassis) SetSpeedLevel(ctx context.Context, level SpeedLevel) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(),
+		dji.DJIRobomasterSystemChassisSpeedLevel, int64(level))
+}
+
+func (c *Chassis) startModeUpdates() {
+	service.DJICommandControllerInstance().StartListeningOnKey(
+		dji.DJIRobomasterChassisMode, c, c.onMode, true)
+}
+
+func (c *Chassis) stopModeUpdates() {
+	service.DJICommandControllerInstance().StopListeningOnKey(
+		dji.DJIRobomasterChassisMode, c)
+}
+
+func (c *Chassis) onMode(result *dji.DJIResult) {
+	var value int64
+	if !service.DecodeResult(c.logger, result, &value) {
+		return
+	}
+
+	c.publishMode(Mode(value))
+}
+
+// publishMode notifies subscribers about the given mode if it is different
+// from the last one.
+func (c *Chassis) publishMode(mode Mode) {
+	if last, ok := c.modeStream.Latest(); ok && last.Mode == mode {
+		return
+	}
+
+	c.modeStream.Publish(ModeUpdate{
+		Time: time.Now(),
+		Mode: mode,
+	})
+}
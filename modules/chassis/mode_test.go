@@ -0,0 +1,185 @@
+package chassis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+)
+
+// The values are the ones the robot uses, so they must never change.
+func TestModes_Values(t *testing.T) {
+	tests := []struct {
+		value          int64
+		name           string
+		expectedValue  int64
+		expectedString string
+	}{
+		{int64(ModeFree), ModeFree.String(), 0, "Free"},
+		{int64(ModeChassisFollowsGimbal), ModeChassisFollowsGimbal.String(),
+			1, "ChassisFollowsGimbal"},
+		{int64(ModeGimbalFollowsChassis), ModeGimbalFollowsChassis.String(),
+			2, "GimbalFollowsChassis"},
+		{3, Mode(3).String(), 3, "Unknown(3)"},
+		{int64(FollowModeNone), FollowModeNone.String(), 0, "None"},
+		{int64(FollowModeGimbal), FollowModeGimbal.String(), 1, "Gimbal"},
+		{2, FollowMode(2).String(), 2, "Unknown(2)"},
+		{int64(SpeedModeNormal), SpeedModeNormal.String(), 0, "Normal"},
+		{int64(SpeedModeFast), SpeedModeFast.String(), 1, "Fast"},
+		{2, SpeedMode(2).String(), 2, "Unknown(2)"},
+		{int64(CarControlModeMecanum), CarControlModeMecanum.String(), 0,
+			"Mecanum"},
+		{int64(CarControlModeCar), CarControlModeCar.String(), 1, "Car"},
+		{2, CarControlMode(2).String(), 2, "Unknown(2)"},
+		{int64(SpeedLevelLow), SpeedLevelLow.String(), 0, "Low"},
+		{int64(SpeedLevelMedium), SpeedLevelMedium.String(), 1, "Medium"},
+		{int64(SpeedLevelHigh), SpeedLevelHigh.String(), 2, "High"},
+		{3, SpeedLevel(3).String(), 3, "Unknown(3)"},
+	}
+
+	for _, test := range tests {
+		if test.value != test.expectedValue {
+			t.Errorf("%s: expected value %d, got %d", test.expectedString,
+				test.expectedValue, test.value)
+		}
+		if test.name != test.expectedString {
+			t.Errorf("expected %q, got %q", test.expectedString, test.name)
+		}
+	}
+}
+
+func TestChassis_Modes_RoundTrip(t *testing.T) {
+	c := newTestChassis(t)
+	cc := service.DJICommandControllerInstance().(
+		*servicetest.CommandController)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		key   dji.DJIKeys
+		value int64
+		set   func() error
+		get   func() (int64, error)
+	}{
+		{"Mode", dji.DJIRobomasterChassisMode, 2,
+			func() error {
+				return c.SetMode(ctx, ModeGimbalFollowsChassis)
+			},
+			func() (int64, error) {
+				mode, err := c.Mode(ctx)
+				return int64(mode), err
+			}},
+		{"FollowMode", dji.DJIMainControllerChassisFollowMode, 1,
+			func() error {
+				return c.SetFollowMode(ctx, FollowModeGimbal)
+			},
+			func() (int64, error) {
+				mode, err := c.FollowMode(ctx)
+				return int64(mode), err
+			}},
+		{"SpeedMode", dji.DJIMainControllerChassisSpeedMode, 1,
+			func() error {
+				return c.SetSpeedMode(ctx, SpeedModeFast)
+			},
+			func() (int64, error) {
+				mode, err := c.SpeedMode(ctx)
+				return int64(mode), err
+			}},
+		{"CarControlMode", dji.DJIMainControllerChassisCarControlMode, 1,
+			func() error {
+				return c.SetCarControlMode(ctx, CarControlModeCar)
+			},
+			func() (int64, error) {
+				mode, err := c.CarControlMode(ctx)
+				return int64(mode), err
+			}},
+		{"SpeedLevel", dji.DJIRobomasterSystemChassisSpeedLevel, 2,
+			func() error {
+				return c.SetSpeedLevel(ctx, SpeedLevelHigh)
+			},
+			func() (int64, error) {
+				level, err := c.SpeedLevel(ctx)
+				return int64(level), err
+			}},
+	}
+
+	for _, test := range tests {
+		_, err := test.get()
+		if err == nil {
+			t.Errorf("%s: expected non-nil error for unset value, got nil",
+				test.name)
+		}
+
+		err = test.set()
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %q", test.name, err)
+		}
+
+		// What was actually sent to the robot.
+		value, ok := cc.Value(test.key)
+		if !ok || value != float64(test.value) {
+			t.Errorf("%s: expected %d sent, got %v", test.name, test.value,
+				value)
+		}
+
+		got, err := test.get()
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %q", test.name, err)
+		}
+		if got != test.value {
+			t.Errorf("%s: expected %d, got %d", test.name, test.value, got)
+		}
+
+		cc.SetErrorCode(test.key, 1)
+
+		err = test.set()
+		if err == nil {
+			t.Errorf("%s: expected non-nil error, got nil", test.name)
+		}
+	}
+}
+
+func TestChassis_SubscribeMode(t *testing.T) {
+	c := newTestChassis(t)
+	cc := service.DJICommandControllerInstance().(
+		*servicetest.CommandController)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	modeChan := c.SubscribeMode(ctx)
+
+	c.startModeUpdates()
+	defer c.stopModeUpdates()
+
+	cc.Publish(dji.DJIRobomasterChassisMode, 1)
+
+	select {
+	case update := <-modeChan:
+		if update.Mode != ModeChassisFollowsGimbal {
+			t.Fatalf("expected mode %s, got %s", ModeChassisFollowsGimbal,
+				update.Mode)
+		}
+	case <-ctx.Done():
+		t.Fatalf("expected mode update, got none")
+	}
+
+	err := c.SetMode(ctx, ModeFree)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	select {
+	case update := <-modeChan:
+		if update.Mode != ModeFree {
+			t.Fatalf("expected mode %s, got %s", ModeFree, update.Mode)
+		}
+	case <-ctx.Done():
+		t.Fatalf("expected mode update, got none")
+	}
+}
@@ -0,0 +1,239 @@
+package chassis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Rate at which the move controller updates the chassis velocity.
+	moveControlInterval = 50 * time.Millisecond
+
+	// Maximum time without position feedback before a move is aborted.
+	moveFeedbackTimeout = time.Second
+)
+
+var (
+	// ErrMoveTimeout is returned when a move does not reach its target within
+	// the configured timeout.
+	ErrMoveTimeout = errors.New("timeout moving chassis")
+
+	// ErrNoFeedback is returned when a move is aborted because there are no
+	// position updates from the robot.
+	ErrNoFeedback = errors.New("no chassis position feedback")
+)
+
+// MoveOptions controls how MoveTo drives the chassis. All options but Timeout
+// must be positive, so start from DefaultMoveOptions instead of a zero value.
+type MoveOptions struct {
+	// Maximum translation speed (m/s) and acceleration (m/s^2).
+	MaxSpeed        float64
+	MaxAcceleration float64
+
+	// Maximum rotation speed (degrees/s) and acceleration (degrees/s^2).
+	MaxRotationSpeed        float64
+	MaxRotationAcceleration float64
+
+	// Proportional gains used when close to the target, for translation (1/s)
+	// and rotation (1/s).
+	Kp         float64
+	KpRotation float64
+
+	// The move is considered done when the chassis is within these distance
+	// (m) and angle (degrees) from the target.
+	PositionTolerance float64
+	YawTolerance      float64
+
+	// Maximum duration of the move. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// DefaultMoveOptions returns conservative options for MoveTo.
+func DefaultMoveOptions() MoveOptions {
+	return MoveOptions{
+		MaxSpeed:                0.5,
+		MaxAcceleration:         0.5,
+		MaxRotationSpeed:        90,
+		MaxRotationAcceleration: 180,
+		Kp:                      2,
+		KpRotation:              3,
+		PositionTolerance:       0.02,
+		YawTolerance:            2,
+		Timeout:                 30 * time.Second,
+	}
+}
+
+// MoveTo drives the chassis to a pose relative to its current one: dx meters
+// forward, dy meters to the right and rotated dyaw degrees clockwise. Speeds
+// follow a trapezoidal profile, using the odometry estimate as feedback. It
+// returns when the target is reached within the tolerances (nil error), on
+// timeout (ErrMoveTimeout), when position feedback stops (ErrNoFeedback) or
+// when the given context is done. The chassis is braked in all cases. Invalid
+// options (see MoveOptions) are reported as an error without moving.
+func (c *Chassis) MoveTo(ctx context.Context, dx, dy, dyaw float64,
+	opts MoveOptions) error {
+	err := opts.validate()
+	if err != nil {
+		return fmt.Errorf("invalid move options: %w", err)
+	}
+
+	moveCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		moveCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	defer c.Brake()
+
+	start, lastUpdate := c.odometry.Pose()
+	target := composePose(start, Pose{dx, dy, dyaw})
+
+	controller := newMoveController(opts)
+
+	ticker := time.NewTicker(moveControlInterval)
+	defer ticker.Stop()
+
+	lastTick := time.Now()
+	for {
+		select {
+		case <-moveCtx.Done():
+			if ctx.Err() != nil {
+				// The caller context is done (maybe its own deadline).
+				return fmt.Errorf("error moving chassis: %w", ctx.Err())
+			}
+			return ErrMoveTimeout
+		case now := <-ticker.C:
+			pose, updated := c.odometry.Pose()
+			if updated.After(lastUpdate) {
+				lastUpdate = updated
+			} else if now.Sub(lastUpdate) > moveFeedbackTimeout {
+				return ErrNoFeedback
+			}
+
+			velocity, done := controller.step(pose, target,
+				now.Sub(lastTick).Seconds())
+			lastTick = now
+
+			if done {
+				return nil
+			}
+
+			c.SetVelocity(velocity.Vx, velocity.Vy, velocity.Wz)
+		}
+	}
+}
+
+// validate returns a nil error if the options can drive the chassis to a
+// target and a non-nil error otherwise. With zero speeds the chassis would
+// never move and with zero tolerances the target would never be reached.
+func (o MoveOptions) validate() error {
+	for _, option := range []struct {
+		name  string
+		value float64
+	}{
+		{"MaxSpeed", o.MaxSpeed},
+		{"MaxAcceleration", o.MaxAcceleration},
+		{"MaxRotationSpeed", o.MaxRotationSpeed},
+		{"MaxRotationAcceleration", o.MaxRotationAcceleration},
+		{"Kp", o.Kp},
+		{"KpRotation", o.KpRotation},
+		{"PositionTolerance", o.PositionTolerance},
+		{"YawTolerance", o.YawTolerance},
+	} {
+		// Also catches NaN.
+		if !(option.value > 0) {
+			return fmt.Errorf("%s must be positive, got %v", option.name,
+				option.value)
+		}
+	}
+
+	if o.Timeout < 0 {
+		return fmt.Errorf("negative timeout %s", o.Timeout)
+	}
+
+	return nil
+}
+
+// moveController computes the velocity needed to drive to a target pose.
+type moveController struct {
+	opts MoveOptions
+
+	speed         float64
+	rotationSpeed float64
+}
+
+func newMoveController(opts MoveOptions) *moveController {
+	return &moveController{
+		opts: opts,
+	}
+}
+
+// step returns the body velocity to use given the current and target poses and
+// the time (in seconds) since the previous step. Returns true if the target
+// was reached.
+func (m *moveController) step(pose, target Pose, dt float64) (Velocity, bool) {
+	// Error in the body frame.
+	errPose := relativePose(pose, target)
+
+	distance := math.Hypot(errPose.X, errPose.Y)
+	yawError := errPose.Yaw
+
+	if distance <= m.opts.PositionTolerance &&
+		math.Abs(yawError) <= m.opts.YawTolerance {
+		return Velocity{}, true
+	}
+
+	m.speed = profileSpeed(m.speed, distance, m.opts.MaxSpeed,
+		m.opts.MaxAcceleration, m.opts.Kp, dt)
+	m.rotationSpeed = profileSpeed(m.rotationSpeed, math.Abs(yawError),
+		m.opts.MaxRotationSpeed, m.opts.MaxRotationAcceleration,
+		m.opts.KpRotation, dt)
+
+	var velocity Velocity
+	if distance > m.opts.PositionTolerance {
+		velocity.Vx = m.speed * errPose.X / distance
+		velocity.Vy = m.speed * errPose.Y / distance
+	}
+	if math.Abs(yawError) > m.opts.YawTolerance {
+		velocity.Wz = math.Copysign(m.rotationSpeed, yawError)
+	}
+
+	return velocity, false
+}
+
+// profileSpeed returns the speed to use to cover the remaining distance
+// following a trapezoidal profile: accelerate up to maxSpeed, cruise and then
+// decelerate so we stop at the target. Close to the target, a proportional
+// term takes over for a smooth approach.
+func profileSpeed(speed, remaining, maxSpeed, maxAcceleration, kp,
+	dt float64) float64 {
+	// Ramp up.
+	speed += maxAcceleration * dt
+
+	// Cruise.
+	speed = math.Min(speed, maxSpeed)
+
+	// Ramp down so we can stop in the remaining distance.
+	speed = math.Min(speed, math.Sqrt(2*maxAcceleration*remaining))
+
+	// Final approach.
+	speed = math.Min(speed, kp*remaining)
+
+	return speed
+}
+
+// composePose returns the pose obtained by applying the given relative pose
+// (in the body frame of the given pose) to the given pose.
+func composePose(pose, relative Pose) Pose {
+	sin, cos := math.Sincos(pose.Yaw * math.Pi / 180)
+
+	return Pose{
+		X:   pose.X + relative.X*cos - relative.Y*sin,
+		Y:   pose.Y + relative.X*sin + relative.Y*cos,
+		Yaw: normalizeAngle(pose.Yaw + relative.Yaw),
+	}
+}
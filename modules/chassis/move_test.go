@@ -0,0 +1,264 @@
+package chassis
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+	"github.com/brunoga/robomaster2/modules/robot"
+	"github.com/brunoga/robomaster2/support"
+)
+
+func TestMoveOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*MoveOptions)
+		valid  bool
+	}{
+		{"Default", func(o *MoveOptions) {}, true},
+		{"NoTimeout", func(o *MoveOptions) { o.Timeout = 0 }, true},
+		{"Zero", func(o *MoveOptions) { *o = MoveOptions{} }, false},
+		{"ZeroMaxSpeed", func(o *MoveOptions) { o.MaxSpeed = 0 }, false},
+		{"ZeroMaxAcceleration",
+			func(o *MoveOptions) { o.MaxAcceleration = 0 }, false},
+		{"ZeroMaxRotationSpeed",
+			func(o *MoveOptions) { o.MaxRotationSpeed = 0 }, false},
+		{"ZeroMaxRotationAcceleration",
+			func(o *MoveOptions) { o.MaxRotationAcceleration = 0 }, false},
+		{"ZeroKp", func(o *MoveOptions) { o.Kp = 0 }, false},
+		{"ZeroKpRotation", func(o *MoveOptions) { o.KpRotation = 0 }, false},
+		{"ZeroPositionTolerance",
+			func(o *MoveOptions) { o.PositionTolerance = 0 }, false},
+		{"ZeroYawTolerance", func(o *MoveOptions) { o.YawTolerance = 0 },
+			false},
+		{"NegativeMaxSpeed", func(o *MoveOptions) { o.MaxSpeed = -1 }, false},
+		{"NaNKp", func(o *MoveOptions) { o.Kp = math.NaN() }, false},
+		{"NegativeTimeout", func(o *MoveOptions) { o.Timeout = -time.Second },
+			false},
+	}
+
+	for _, test := range tests {
+		opts := DefaultMoveOptions()
+		test.modify(&opts)
+
+		err := opts.validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected nil error, got %q", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected non-nil error, got nil", test.name)
+		}
+	}
+}
+
+func TestProfileSpeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		speed     float64
+		remaining float64
+		expected  float64
+	}{
+		{"RampUp", 0, 10, 0.1},
+		{"Cruise", 2, 10, 2},
+		{"CruiseFromAbove", 3, 10, 2},
+		// sqrt(2 * 1 * 0.72) = 1.2, below 2 * 0.72 = 1.44.
+		{"RampDown", 2, 0.72, 1.2},
+		// 2 * 0.05 = 0.1, below sqrt(2 * 1 * 0.05) = 0.316.
+		{"FinalApproach", 1, 0.05, 0.1},
+		{"AtTarget", 1, 0, 0},
+	}
+
+	for _, test := range tests {
+		speed := profileSpeed(test.speed, test.remaining, 2, 1, 2, 0.1)
+		if !almostEqual(speed, test.expected) {
+			t.Errorf("%s: expected speed %.3f, got %.3f", test.name,
+				test.expected, speed)
+		}
+	}
+}
+
+func TestComposePose(t *testing.T) {
+	tests := []struct {
+		name     string
+		pose     Pose
+		relative Pose
+		expected Pose
+	}{
+		{"Identity", Pose{1, 2, 30}, Pose{}, Pose{1, 2, 30}},
+		{"Forward", Pose{1, 2, 0}, Pose{1, 0, 0}, Pose{2, 2, 0}},
+		{"Right", Pose{1, 2, 0}, Pose{0, 1, 0}, Pose{1, 3, 0}},
+		// Facing right, forward is +Y and right is -X.
+		{"RotatedForward", Pose{1, 1, 90}, Pose{1, 0, 0}, Pose{1, 2, 90}},
+		{"RotatedRight", Pose{1, 1, 90}, Pose{0, 1, 0}, Pose{0, 1, 90}},
+		{"Rotation", Pose{0, 0, 30}, Pose{0, 0, 60}, Pose{0, 0, 90}},
+		{"YawWrap", Pose{0, 0, 170}, Pose{0, 0, 20}, Pose{0, 0, -170}},
+	}
+
+	for _, test := range tests {
+		pose := composePose(test.pose, test.relative)
+		if !poseAlmostEqual(pose, test.expected) {
+			t.Errorf("%s: expected pose %s, got %s", test.name,
+				test.expected, pose)
+		}
+	}
+}
+
+func TestMoveController_Step(t *testing.T) {
+	opts := DefaultMoveOptions()
+
+	tests := []struct {
+		name   string
+		target Pose
+	}{
+		{"Forward", Pose{1, 0, 0}},
+		{"Backward", Pose{-0.5, 0, 0}},
+		{"Diagonal", Pose{0.5, -0.5, 0}},
+		{"Rotation", Pose{0, 0, -90}},
+		{"Combined", Pose{0.5, 0.3, 45}},
+	}
+
+	const dt = 0.05
+
+	for _, test := range tests {
+		controller := newMoveController(opts)
+
+		var pose Pose
+		var previous Velocity
+		done := false
+		for i := 0; i < 1000 && !done; i++ {
+			var velocity Velocity
+			velocity, done = controller.step(pose, test.target, dt)
+
+			speed := math.Hypot(velocity.Vx, velocity.Vy)
+			if speed > opts.MaxSpeed+epsilon {
+				t.Fatalf("%s: expected speed up to %.3f, got %.3f",
+					test.name, opts.MaxSpeed, speed)
+			}
+			if speed-math.Hypot(previous.Vx, previous.Vy) >
+				opts.MaxAcceleration*dt+epsilon {
+				t.Fatalf("%s: expected acceleration up to %.3f, got a "+
+					"speed change from %s to %s", test.name,
+					opts.MaxAcceleration, previous, velocity)
+			}
+			if math.Abs(velocity.Wz) > opts.MaxRotationSpeed+epsilon {
+				t.Fatalf("%s: expected rotation speed up to %.3f, got %.3f",
+					test.name, opts.MaxRotationSpeed, velocity.Wz)
+			}
+
+			pose = integrateVelocity(pose, velocity, dt)
+			previous = velocity
+		}
+
+		if !done {
+			t.Fatalf("%s: expected target %s to be reached, got %s",
+				test.name, test.target, pose)
+		}
+
+		errPose := relativePose(pose, test.target)
+		if math.Hypot(errPose.X, errPose.Y) > opts.PositionTolerance ||
+			math.Abs(errPose.Yaw) > opts.YawTolerance {
+			t.Fatalf("%s: expected pose within tolerance of %s, got %s",
+				test.name, test.target, pose)
+		}
+	}
+}
+
+// simulateChassis feeds the odometry of the given chassis with the pose
+// obtained by integrating its commanded velocity until the given context is
+// done. If frozen is true, the pose never changes.
+func simulateChassis(ctx context.Context, c *Chassis, frozen bool) {
+	const interval = 10 * time.Millisecond
+
+	var pose Pose
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if !frozen {
+			pose = integrateVelocity(pose, c.Velocity(), interval.Seconds())
+		}
+
+		c.odometry.UpdatePosition(PositionUpdate{time.Now(), pose})
+	}
+}
+
+func newTestChassis(t *testing.T) *Chassis {
+	servicetest.Install(t)
+
+	r := robot.NewRobot(support.NewLogger(nil, nil, nil, nil))
+	t.Cleanup(r.Stop)
+
+	return New(support.NewLogger(nil, nil, nil, nil), r)
+}
+
+func TestChassis_MoveTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		frozen   bool
+		timeout  time.Duration
+		expected error
+	}{
+		{"Reached", false, 5 * time.Second, nil},
+		{"Timeout", true, 300 * time.Millisecond, ErrMoveTimeout},
+	}
+
+	for _, test := range tests {
+		// Each move gets its own fake controller and robot.
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestChassis(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				simulateChassis(ctx, c, test.frozen)
+			}()
+
+			opts := DefaultMoveOptions()
+			opts.MaxSpeed = 2
+			opts.MaxAcceleration = 4
+			opts.Timeout = test.timeout
+
+			err := c.MoveTo(context.Background(), 0.2, 0.1, 0, opts)
+
+			cancel()
+			wg.Wait()
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v, got %v", test.expected, err)
+			}
+
+			velocity := c.Velocity()
+			if velocity != (Velocity{}) {
+				t.Errorf("expected chassis braked, got %s", velocity)
+			}
+		})
+	}
+}
+
+func TestChassis_MoveTo_InvalidOptions(t *testing.T) {
+	c := newTestChassis(t)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.MoveTo(context.Background(), 1, 0, 0, MoveOptions{})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected non-nil error, got nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected MoveTo to return, it is still running")
+	}
+}
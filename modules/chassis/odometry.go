@@ -0,0 +1,155 @@
+package chassis
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster2/modules/chassis/kinematics"
+)
+
+const (
+	// Velocity updates are only integrated if they are at most this far
+	// apart. Anything longer means we missed updates and integrating would
+	// add a large error.
+	maxOdometryIntegrationInterval = 500 * time.Millisecond
+)
+
+// Odometry keeps a chassis pose estimate relative to an origin (the pose at
+// the time of the last Reset). Position updates reported by the robot are
+// used when available and velocity updates are integrated in between them.
+// Wheel speeds are integrated instead when there are no velocity updates.
+type Odometry struct {
+	m                  sync.Mutex
+	geometry           kinematics.Geometry
+	origin             Pose
+	resetOnPos         bool
+	pose               Pose
+	lastUpdate         time.Time
+	lastVelocityUpdate time.Time
+}
+
+// NewOdometry returns a new Odometry instance for the S1/EP chassis geometry
+// with the origin at the first position reported.
+func NewOdometry() *Odometry {
+	return &Odometry{
+		geometry:   kinematics.S1,
+		resetOnPos: true,
+	}
+}
+
+// Reset makes the current pose the origin. The pose estimate becomes zero.
+func (o *Odometry) Reset() {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	o.pose = Pose{}
+
+	// The next position reported becomes the new origin.
+	o.resetOnPos = true
+}
+
+// Pose returns the current pose estimate relative to the origin and the time
+// it was last updated.
+func (o *Odometry) Pose() (Pose, time.Time) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	return o.pose, o.lastUpdate
+}
+
+// UpdatePosition updates the pose estimate with a position reported by the
+// robot.
+func (o *Odometry) UpdatePosition(update PositionUpdate) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if o.resetOnPos {
+		o.origin = update.Pose
+		o.resetOnPos = false
+	}
+
+	o.pose = relativePose(o.origin, update.Pose)
+	o.lastUpdate = update.Time
+}
+
+// UpdateVelocity updates the pose estimate by integrating a body velocity
+// reported by the robot.
+func (o *Odometry) UpdateVelocity(update SpeedUpdate) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	o.lastVelocityUpdate = update.Time
+
+	o.integrateLocked(update.Velocity, update.Time)
+}
+
+// UpdateWheelSpeed updates the pose estimate by integrating the body velocity
+// corresponding to the wheel speeds reported by the robot. Ignored while
+// velocity updates are being received, as those are more accurate.
+func (o *Odometry) UpdateWheelSpeed(update WheelSpeedUpdate) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if update.Time.Sub(o.lastVelocityUpdate) <= maxOdometryIntegrationInterval {
+		return
+	}
+
+	twist := o.geometry.Forward(update.WheelSpeeds())
+
+	o.integrateLocked(Velocity{twist.Vx, twist.Vy, twist.Wz}, update.Time)
+}
+
+func (o *Odometry) integrateLocked(velocity Velocity, now time.Time) {
+	dt := now.Sub(o.lastUpdate)
+	o.lastUpdate = now
+
+	if dt <= 0 || dt > maxOdometryIntegrationInterval {
+		return
+	}
+
+	o.pose = integrateVelocity(o.pose, velocity, dt.Seconds())
+}
+
+// relativePose returns the given pose expressed in the frame of the given
+// origin.
+func relativePose(origin, pose Pose) Pose {
+	dx := pose.X - origin.X
+	dy := pose.Y - origin.Y
+
+	sin, cos := math.Sincos(origin.Yaw * math.Pi / 180)
+
+	return Pose{
+		X:   dx*cos + dy*sin,
+		Y:   -dx*sin + dy*cos,
+		Yaw: normalizeAngle(pose.Yaw - origin.Yaw),
+	}
+}
+
+// integrateVelocity returns the pose reached after moving with the given body
+// velocity for dt seconds starting at the given pose.
+func integrateVelocity(pose Pose, velocity Velocity, dt float64) Pose {
+	// Use the heading at the middle of the interval for better accuracy
+	// while turning.
+	yaw := pose.Yaw + velocity.Wz*dt/2
+	sin, cos := math.Sincos(yaw * math.Pi / 180)
+
+	return Pose{
+		X:   pose.X + (velocity.Vx*cos-velocity.Vy*sin)*dt,
+		Y:   pose.Y + (velocity.Vx*sin+velocity.Vy*cos)*dt,
+		Yaw: normalizeAngle(pose.Yaw + velocity.Wz*dt),
+	}
+}
+
+// normalizeAngle returns the given angle (in degrees) in the (-180, 180]
+// range.
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 360)
+	if angle > 180 {
+		angle -= 360
+	} else if angle <= -180 {
+		angle += 360
+	}
+
+	return angle
+}
@@ -0,0 +1,150 @@
+package chassis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const epsilon = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+func poseAlmostEqual(a, b Pose) bool {
+	return almostEqual(a.X, b.X) && almostEqual(a.Y, b.Y) &&
+		almostEqual(a.Yaw, b.Yaw)
+}
+
+func TestNormalizeAngle(t *testing.T) {
+	tests := []struct {
+		angle    float64
+		expected float64
+	}{
+		{0, 0},
+		{90, 90},
+		{-90, -90},
+		{180, 180},
+		{-180, 180},
+		{181, -179},
+		{-181, 179},
+		{270, -90},
+		{360, 0},
+		{540, 180},
+		{-540, 180},
+		{725, 5},
+	}
+
+	for _, test := range tests {
+		got := normalizeAngle(test.angle)
+		if !almostEqual(got, test.expected) {
+			t.Errorf("normalizeAngle(%v): expected %v, got %v", test.angle,
+				test.expected, got)
+		}
+	}
+}
+
+func TestRelativePose(t *testing.T) {
+	tests := []struct {
+		name     string
+		origin   Pose
+		pose     Pose
+		expected Pose
+	}{
+		{"Identity", Pose{}, Pose{1, 2, 30}, Pose{1, 2, 30}},
+		{"Translated", Pose{1, 1, 0}, Pose{2, 3, 0}, Pose{1, 2, 0}},
+		{"Rotated", Pose{0, 0, 90}, Pose{0, 1, 90}, Pose{1, 0, 0}},
+		{"RotatedBack", Pose{0, 0, -90}, Pose{1, 0, 0}, Pose{0, 1, 90}},
+		{"WrapPositive", Pose{0, 0, 170}, Pose{0, 0, -170}, Pose{0, 0, 20}},
+		{"WrapNegative", Pose{0, 0, -170}, Pose{0, 0, 170},
+			Pose{0, 0, -20}},
+		{"Opposite", Pose{0, 0, 0}, Pose{0, 0, -180}, Pose{0, 0, 180}},
+	}
+
+	for _, test := range tests {
+		got := relativePose(test.origin, test.pose)
+		if !poseAlmostEqual(got, test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected,
+				got)
+		}
+	}
+}
+
+func TestIntegrateVelocity(t *testing.T) {
+	tests := []struct {
+		name     string
+		pose     Pose
+		velocity Velocity
+		dt       float64
+		expected Pose
+	}{
+		{"Still", Pose{1, 2, 3}, Velocity{}, 1, Pose{1, 2, 3}},
+		{"Forward", Pose{}, Velocity{Vx: 1}, 0.5, Pose{0.5, 0, 0}},
+		{"Right", Pose{}, Velocity{Vy: 1}, 2, Pose{0, 2, 0}},
+		{"ForwardWhileFacingRight", Pose{0, 0, 90}, Velocity{Vx: 1}, 1,
+			Pose{0, 1, 90}},
+		{"TurnInPlace", Pose{}, Velocity{Wz: 90}, 1, Pose{0, 0, 90}},
+		{"TurnWrap", Pose{0, 0, 170}, Velocity{Wz: 20}, 1,
+			Pose{0, 0, -170}},
+		{"TurnWrapNegative", Pose{0, 0, -170}, Velocity{Wz: -20}, 1,
+			Pose{0, 0, 170}},
+	}
+
+	for _, test := range tests {
+		got := integrateVelocity(test.pose, test.velocity, test.dt)
+		if !poseAlmostEqual(got, test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected,
+				got)
+		}
+	}
+}
+
+func TestIntegrateVelocity_Arc(t *testing.T) {
+	// Driving forward while turning a quarter circle in small steps ends up
+	// at the corner of a circle with radius 1/(pi/2).
+	pose := Pose{}
+	velocity := Velocity{Vx: 1, Wz: 90}
+
+	for i := 0; i < 1000; i++ {
+		pose = integrateVelocity(pose, velocity, 0.001)
+	}
+
+	radius := 2 / math.Pi
+	if math.Abs(pose.X-radius) > 1e-6 || math.Abs(pose.Y-radius) > 1e-6 ||
+		math.Abs(pose.Yaw-90) > 1e-6 {
+		t.Fatalf("expected pose at (%f, %f, 90), got %s", radius, radius,
+			pose)
+	}
+}
+
+func TestOdometry_UpdatePosition_Wrap(t *testing.T) {
+	o := NewOdometry()
+	now := time.Now()
+
+	o.UpdatePosition(PositionUpdate{now, Pose{1, 1, 175}})
+	o.UpdatePosition(PositionUpdate{now.Add(time.Second),
+		Pose{1, 1, -175}})
+
+	pose, _ := o.Pose()
+	if !poseAlmostEqual(pose, Pose{0, 0, 10}) {
+		t.Fatalf("expected 10 degrees turn, got %s", pose)
+	}
+}
+
+func TestOdometry_UpdateVelocity(t *testing.T) {
+	o := NewOdometry()
+	now := time.Now()
+
+	o.UpdateVelocity(SpeedUpdate{now, Velocity{Vx: 1}})
+	o.UpdateVelocity(SpeedUpdate{now.Add(100 * time.Millisecond),
+		Velocity{Vx: 1}})
+
+	// Gaps longer than the maximum integration interval are not integrated.
+	o.UpdateVelocity(SpeedUpdate{now.Add(time.Second), Velocity{Vx: 1}})
+
+	pose, _ := o.Pose()
+	if !poseAlmostEqual(pose, Pose{0.1, 0, 0}) {
+		t.Fatalf("expected 0.1 m forward, got %s", pose)
+	}
+}
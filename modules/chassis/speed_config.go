@@ -0,0 +1,273 @@
+package chassis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+// SpeedConfig holds the chassis speed limits and acceleration profile
+// configured in the robot. Speeds are in m/s and acceleration and brake
+// slopes are in m/s^2. X is the forward axis and Y is the lateral one. When
+// setting a configuration, zero fields leave the current values unchanged.
+type SpeedConfig struct {
+	MaxSpeedForward  float64
+	MaxSpeedBackward float64
+	MaxSpeedLateral  float64
+
+	AccelerationX float64
+	AccelerationY float64
+	BrakeX        float64
+	BrakeY        float64
+}
+
+func (s SpeedConfig) String() string {
+	return fmt.Sprintf("MaxSpeedForward:%.2f, MaxSpeedBackward:%.2f, "+
+		"MaxSpeedLateral:%.2f, AccelerationX:%.2f, AccelerationY:%.2f, "+
+		"BrakeX:%.2f, BrakeY:%.2f", s.MaxSpeedForward, s.MaxSpeedBackward,
+		s.MaxSpeedLateral, s.AccelerationX, s.AccelerationY, s.BrakeX,
+		s.BrakeY)
+}
+
+// Preset is a named SpeedConfig.
+type Preset string
+
+const (
+	// Low speeds and gentle acceleration, for kids driving indoors.
+	PresetClassroom Preset = "classroom"
+
+	// Moderate speeds for demonstrations in crowded spaces.
+	PresetDemo Preset = "demo"
+
+	// Full robot speed and acceleration.
+	PresetOutdoor Preset = "outdoor"
+)
+
+var (
+	presetSpeedConfigs = map[Preset]SpeedConfig{
+		PresetClassroom: {
+			MaxSpeedForward:  1.0,
+			MaxSpeedBackward: 0.8,
+			MaxSpeedLateral:  0.8,
+			AccelerationX:    1.0,
+			AccelerationY:    1.0,
+			BrakeX:           2.0,
+			BrakeY:           2.0,
+		},
+		PresetDemo: {
+			MaxSpeedForward:  2.0,
+			MaxSpeedBackward: 1.5,
+			MaxSpeedLateral:  1.5,
+			AccelerationX:    2.0,
+			AccelerationY:    2.0,
+			BrakeX:           3.0,
+			BrakeY:           3.0,
+		},
+		PresetOutdoor: {
+			MaxSpeedForward:  3.5,
+			MaxSpeedBackward: 2.5,
+			MaxSpeedLateral:  2.8,
+			AccelerationX:    4.0,
+			AccelerationY:    4.0,
+			BrakeX:           5.0,
+			BrakeY:           5.0,
+		},
+	}
+)
+
+// SpeedConfig returns the SpeedConfig associated with the preset. Returns the
+// config and true if the preset exists and an empty SpeedConfig and false
+// otherwise.
+func (p Preset) SpeedConfig() (SpeedConfig, bool) {
+	config, ok := presetSpeedConfigs[p]
+
+	return config, ok
+}
+
+// SpeedConfig reads the current speed configuration from the robot. Returns
+// the configuration and a nil error on success and an empty SpeedConfig and a
+// non-nil error on failure.
+func (c *Chassis) SpeedConfig(ctx context.Context) (SpeedConfig, error) {
+	var config SpeedConfig
+	for _, field := range config.fields() {
+		value, err := c.getFloat(ctx, field.key)
+		if err != nil {
+			return SpeedConfig{}, err
+		}
+
+		*field.value = value
+	}
+
+	return config, nil
+}
+
+// SetSpeedConfig applies the given speed configuration to the robot. It is
+// applied atomically: if setting any value fails, the values already set are
+// reverted. The configuration the robot had before the first change is
+// restored on Stop (or by calling RestoreSpeedConfig). Returns a nil error on
+// success and a non-nil error on failure.
+func (c *Chassis) SetSpeedConfig(ctx context.Context,
+	config SpeedConfig) error {
+	c.configM.Lock()
+	defer c.configM.Unlock()
+
+	current, err := c.SpeedConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading speed config: %w", err)
+	}
+
+	if c.originalSpeedConfig == nil {
+		original := current
+		c.originalSpeedConfig = &original
+	}
+
+	// Zero fields leave the current values unchanged.
+	currentFields := current.fields()
+	for i, field := range config.fields() {
+		if *field.value == 0 {
+			*field.value = *currentFields[i].value
+		}
+	}
+
+	return c.writeSpeedConfig(ctx, current, config)
+}
+
+// ApplyPreset applies the speed configuration of the given preset (see
+// SetSpeedConfig). Returns a nil error on success and a non-nil error on
+// failure.
+func (c *Chassis) ApplyPreset(ctx context.Context, preset Preset) error {
+	config, ok := preset.SpeedConfig()
+	if !ok {
+		return fmt.Errorf("unknown preset %q", preset)
+	}
+
+	return c.SetSpeedConfig(ctx, config)
+}
+
+// RestoreSpeedConfig restores the speed configuration the robot had before it
+// was first changed with SetSpeedConfig or ApplyPreset. Does nothing if it was
+// never changed. Returns a nil error on success and a non-nil error on failure.
+func (c *Chassis) RestoreSpeedConfig(ctx context.Context) error {
+	c.configM.Lock()
+	defer c.configM.Unlock()
+
+	if c.originalSpeedConfig == nil {
+		return nil
+	}
+
+	current, err := c.SpeedConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading speed config: %w", err)
+	}
+
+	err = c.writeSpeedConfig(ctx, current, *c.originalSpeedConfig)
+	if err != nil {
+		return err
+	}
+
+	c.originalSpeedConfig = nil
+
+	return nil
+}
+
+// writeSpeedConfig changes the robot speed configuration from current to
+// target, reverting any changes if a value fails to be set. All target values
+// are written, including zero ones. Speed limits are updated to match the new
+// configuration.
+func (c *Chassis) writeSpeedConfig(ctx context.Context, current,
+	target SpeedConfig) error {
+	currentFields := current.fields()
+	targetFields := target.fields()
+
+	var changed []speedConfigField
+	for i, field := range targetFields {
+		value := *field.value
+		if value == *currentFields[i].value {
+			continue
+		}
+
+		err := c.setFloat(ctx, field.key, value)
+		if err == nil {
+			changed = append(changed, currentFields[i])
+			continue
+		}
+
+		// Revert whatever we already changed. The given context might be done
+		// already, so use a new one.
+		revertCtx, cancel := context.WithTimeout(context.Background(),
+			time.Second)
+		for _, original := range changed {
+			revertErr := c.setFloat(revertCtx, original.key, *original.value)
+			if revertErr != nil {
+				c.logger.ERROR("Error reverting %s: %s", original.key,
+					revertErr.Error())
+			}
+		}
+		cancel()
+
+		return fmt.Errorf("error setting speed config: %w", err)
+	}
+
+	// Zero speeds can not be used to scale stick input, so keep the current
+	// limits for those.
+	c.m.Lock()
+	for _, limit := range []struct {
+		value  float64
+		target *float64
+	}{
+		{target.MaxSpeedForward, &c.limits.Forward},
+		{target.MaxSpeedBackward, &c.limits.Backward},
+		{target.MaxSpeedLateral, &c.limits.Lateral},
+	} {
+		if limit.value > 0 {
+			*limit.target = limit.value
+		}
+	}
+	c.m.Unlock()
+
+	return nil
+}
+
+type speedConfigField struct {
+	key   dji.DJIKeys
+	value *float64
+}
+
+func (s *SpeedConfig) fields() []speedConfigField {
+	return []speedConfigField{
+		{dji.DJIMainControllerMaxSpeedForwardConfig, &s.MaxSpeedForward},
+		{dji.DJIMainControllerMaxSpeedBackwardConfig, &s.MaxSpeedBackward},
+		{dji.DJIMainControllerMaxSpeedLateralConfig, &s.MaxSpeedLateral},
+		{dji.DJIMainControllerSlopSpeedXConfig, &s.AccelerationX},
+		{dji.DJIMainControllerSlopSpeedYConfig, &s.AccelerationY},
+		{dji.DJIMainControllerSlopBreakXConfig, &s.BrakeX},
+		{dji.DJIMainControllerSlopBreakYConfig, &s.BrakeY},
+	}
+}
+
+func (c *Chassis) getFloat(ctx context.Context, key dji.DJIKeys) (float64,
+	error) {
+	result, err := service.GetValueForKeySync(ctx,
+		service.DJICommandControllerInstance(), key)
+	if err != nil {
+		return 0, err
+	}
+
+	var value float64
+	err = result.DecodeValue(&value)
+	if err != nil {
+		return 0, fmt.Errorf("error for key %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+func (c *Chassis) setFloat(ctx context.Context, key dji.DJIKeys,
+	value float64) error {
+	return service.SetValueForKeySync(ctx,
+		service.DJICommandControllerInstance(), key,
+		dji.NewDJIFloatParamValue(float32(value)))
+}
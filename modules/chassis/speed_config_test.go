@@ -0,0 +1,176 @@
+package chassis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+)
+
+// setTestSpeedConfig makes the given config the one in the fake robot.
+func setTestSpeedConfig(cc *servicetest.CommandController,
+	config SpeedConfig) {
+	for _, field := range config.fields() {
+		cc.SetValue(field.key, *field.value)
+	}
+}
+
+func TestChassis_SpeedConfig_SetAndRestore(t *testing.T) {
+	c := newTestChassis(t)
+	cc := service.DJICommandControllerInstance().(
+		*servicetest.CommandController)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Zero values are valid and must be restored too.
+	original := SpeedConfig{
+		MaxSpeedForward:  3.5,
+		MaxSpeedBackward: 2.5,
+		MaxSpeedLateral:  2.75,
+		AccelerationX:    4,
+		AccelerationY:    0,
+		BrakeX:           5,
+		BrakeY:           0,
+	}
+	setTestSpeedConfig(cc, original)
+
+	err := c.ApplyPreset(ctx, PresetClassroom)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	expected, _ := PresetClassroom.SpeedConfig()
+
+	config, err := c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != expected {
+		t.Fatalf("expected config %s, got %s", expected, config)
+	}
+
+	limits := c.SpeedLimits()
+	if limits.Forward != expected.MaxSpeedForward ||
+		limits.Backward != expected.MaxSpeedBackward ||
+		limits.Lateral != expected.MaxSpeedLateral {
+		t.Fatalf("expected limits to match %s, got %+v", expected, limits)
+	}
+
+	// Zero fields are left unchanged.
+	err = c.SetSpeedConfig(ctx, SpeedConfig{MaxSpeedForward: 1.5})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	expected.MaxSpeedForward = 1.5
+
+	config, err = c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != expected {
+		t.Fatalf("expected config %s, got %s", expected, config)
+	}
+
+	// The config from before the first change is restored.
+	err = c.RestoreSpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	config, err = c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != original {
+		t.Fatalf("expected config %s, got %s", original, config)
+	}
+
+	// Nothing else to restore.
+	setTestSpeedConfig(cc, expected)
+
+	err = c.RestoreSpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	config, err = c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != expected {
+		t.Fatalf("expected config %s, got %s", expected, config)
+	}
+}
+
+func TestChassis_SpeedConfig_ZeroLimits(t *testing.T) {
+	c := newTestChassis(t)
+	cc := service.DJICommandControllerInstance().(
+		*servicetest.CommandController)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	setTestSpeedConfig(cc, SpeedConfig{})
+
+	err := c.ApplyPreset(ctx, PresetDemo)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// Restoring zero max speeds keeps the previous limits.
+	err = c.RestoreSpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	config, err := c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != (SpeedConfig{}) {
+		t.Fatalf("expected zero config, got %s", config)
+	}
+
+	demo, _ := PresetDemo.SpeedConfig()
+
+	limits := c.SpeedLimits()
+	if limits.Forward != demo.MaxSpeedForward {
+		t.Fatalf("expected forward limit %.2f, got %.2f",
+			demo.MaxSpeedForward, limits.Forward)
+	}
+}
+
+func TestChassis_SetSpeedConfig_Revert(t *testing.T) {
+	c := newTestChassis(t)
+	cc := service.DJICommandControllerInstance().(
+		*servicetest.CommandController)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	original, _ := PresetOutdoor.SpeedConfig()
+	setTestSpeedConfig(cc, original)
+
+	// Fails after the max speeds were already set.
+	cc.SetErrorCode(dji.DJIMainControllerSlopSpeedXConfig, 1)
+
+	err := c.ApplyPreset(ctx, PresetClassroom)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	cc.SetErrorCode(dji.DJIMainControllerSlopSpeedXConfig, 0)
+
+	config, err := c.SpeedConfig(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if config != original {
+		t.Fatalf("expected config %s, got %s", original, config)
+	}
+}
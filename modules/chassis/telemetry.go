@@ -0,0 +1,172 @@
+package chassis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/modules/chassis/kinematics"
+)
+
+// Pose represents a chassis pose. X is the forward position and Y is the
+// position to the right, both in meters. Yaw is the clockwise heading in
+// degrees.
+type Pose struct {
+	X   float64
+	Y   float64
+	Yaw float64
+}
+
+func (p Pose) String() string {
+	return fmt.Sprintf("X:%.3f m, Y:%.3f m, Yaw:%.1f deg", p.X, p.Y, p.Yaw)
+}
+
+// SpeedUpdate is the chassis body velocity reported by the robot.
+type SpeedUpdate struct {
+	Time time.Time
+	Velocity
+}
+
+// PositionUpdate is a chassis pose reported by the robot.
+type PositionUpdate struct {
+	Time time.Time
+	Pose
+}
+
+// WheelSpeedUpdate holds the speed of each wheel, in RPM, reported by the
+// robot.
+type WheelSpeedUpdate struct {
+	Time       time.Time
+	FrontRight float64
+	FrontLeft  float64
+	RearLeft   float64
+	RearRight  float64
+}
+
+// WheelSpeeds returns the wheel speeds in the update.
+func (w WheelSpeedUpdate) WheelSpeeds() kinematics.WheelSpeeds {
+	return kinematics.WheelSpeeds{
+		FrontRight: w.FrontRight,
+		FrontLeft:  w.FrontLeft,
+		RearLeft:   w.RearLeft,
+		RearRight:  w.RearRight,
+	}
+}
+
+// SubscribeSpeed returns a channel where chassis body velocity updates are
+// delivered until the given context is done.
+func (c *Chassis) SubscribeSpeed(ctx context.Context) <-chan SpeedUpdate {
+	return c.speedStream.Subscribe(ctx)
+}
+
+// SubscribePosition returns a channel where chassis position updates are
+// delivered until the given context is done.
+func (c *Chassis) SubscribePosition(ctx context.Context) <-chan PositionUpdate {
+	return c.positionStream.Subscribe(ctx)
+}
+
+// SubscribeRelativePosition returns a channel where chassis relative position
+// updates are delivered until the given context is done.
+func (c *Chassis) SubscribeRelativePosition(
+	ctx context.Context) <-chan PositionUpdate {
+	return c.relativePositionStream.Subscribe(ctx)
+}
+
+// SubscribeWheelSpeed returns a channel where wheel speed updates are
+// delivered until the given context is done.
+func (c *Chassis) SubscribeWheelSpeed(
+	ctx context.Context) <-chan WheelSpeedUpdate {
+	return c.wheelSpeedStream.Subscribe(ctx)
+}
+
+// Odometry returns the odometry estimate kept up to date with the chassis
+// telemetry.
+func (c *Chassis) Odometry() *Odometry {
+	return c.odometry
+}
+
+func (c *Chassis) startTelemetry() {
+	cc := service.DJICommandControllerInstance()
+
+	cc.StartListeningOnKey(dji.DJIRobomasterChassisSpeed, c,
+		c.onChassisSpeed, false)
+	cc.StartListeningOnKey(dji.DJIMainControllerChassisPosition, c,
+		c.onChassisPosition, false)
+	cc.StartListeningOnKey(dji.DJIRobomasterMainControllerRelativePosition, c,
+		c.onRelativePosition, false)
+	cc.StartListeningOnKey(dji.DJIMainControllerWheelSpeed, c,
+		c.onWheelSpeed, false)
+}
+
+func (c *Chassis) stopTelemetry() {
+	cc := service.DJICommandControllerInstance()
+
+	cc.StopListeningOnKey(dji.DJIRobomasterChassisSpeed, c)
+	cc.StopListeningOnKey(dji.DJIMainControllerChassisPosition, c)
+	cc.StopListeningOnKey(dji.DJIRobomasterMainControllerRelativePosition, c)
+	cc.StopListeningOnKey(dji.DJIMainControllerWheelSpeed, c)
+
+	cc.PerformAction(dji.DJIRobomasterCloseChassisSpeedUpdates, nil)
+}
+
+func (c *Chassis) onChassisSpeed(result *dji.DJIResult) {
+	var value dji.DJIChassisSpeedParamValue
+	if !service.DecodeResult(c.logger, result, &value) {
+		return
+	}
+
+	update := SpeedUpdate{
+		Time:     time.Now(),
+		Velocity: Velocity{value.X, value.Y, value.Z},
+	}
+
+	c.odometry.UpdateVelocity(update)
+	c.speedStream.Publish(update)
+}
+
+func (c *Chassis) onChassisPosition(result *dji.DJIResult) {
+	var value dji.DJIChassisPositionParamValue
+	if !service.DecodeResult(c.logger, result, &value) {
+		return
+	}
+
+	update := PositionUpdate{
+		Time: time.Now(),
+		Pose: Pose{value.X, value.Y, value.Z},
+	}
+
+	c.odometry.UpdatePosition(update)
+	c.positionStream.Publish(update)
+}
+
+func (c *Chassis) onRelativePosition(result *dji.DJIResult) {
+	var value dji.DJIChassisPositionParamValue
+	if !service.DecodeResult(c.logger, result, &value) {
+		return
+	}
+
+	c.relativePositionStream.Publish(PositionUpdate{
+		Time: time.Now(),
+		Pose: Pose{value.X, value.Y, value.Z},
+	})
+}
+
+func (c *Chassis) onWheelSpeed(result *dji.DJIResult) {
+	var value dji.DJIWheelSpeedParamValue
+	if !service.DecodeResult(c.logger, result, &value) {
+		return
+	}
+
+	update := WheelSpeedUpdate{
+		Time:       time.Now(),
+		FrontRight: float64(value.FrontRight),
+		FrontLeft:  float64(value.FrontLeft),
+		RearLeft:   float64(value.RearLeft),
+		RearRight:  float64(value.RearRight),
+	}
+
+	c.odometry.UpdateWheelSpeed(update)
+	c.wheelSpeedStream.Publish(update)
+}
@@ -0,0 +1,241 @@
+package chassis
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Speed used for waypoints that do not specify one, in m/s.
+	defaultWaypointSpeed = 0.5
+
+	// Rotation speed used to compute how long turning towards a waypoint
+	// heading takes, in degrees/s.
+	defaultWaypointRotationSpeed = 90
+
+	// Number of poses used to describe each figure-eight loop.
+	figureEightSamples = 64
+)
+
+// TrajectoryPoint is a pose the chassis should be at a given time since the
+// start of the trajectory.
+type TrajectoryPoint struct {
+	Time time.Duration
+	Pose
+}
+
+// Waypoint is a pose the chassis should go through and the speed (in m/s) to
+// use to get there from the previous one. A zero speed means a default speed.
+type Waypoint struct {
+	Pose
+	Speed float64
+}
+
+// Trajectory is a time-parameterized sequence of poses, relative to the pose
+// the chassis has when it starts following it. Poses in between points are
+// linearly interpolated.
+type Trajectory struct {
+	points []TrajectoryPoint
+}
+
+// NewTrajectory returns a Trajectory going through the given points. There
+// must be at least one point and times must be strictly increasing. Returns
+// the trajectory and a nil error on success and an empty Trajectory and a
+// non-nil error on failure.
+func NewTrajectory(points ...TrajectoryPoint) (Trajectory, error) {
+	if len(points) == 0 {
+		return Trajectory{}, fmt.Errorf("trajectory must have at least one " +
+			"point")
+	}
+
+	if points[0].Time < 0 {
+		return Trajectory{}, fmt.Errorf("trajectory times must not be " +
+			"negative")
+	}
+
+	for i := 1; i < len(points); i++ {
+		if points[i].Time <= points[i-1].Time {
+			return Trajectory{}, fmt.Errorf("trajectory times must be " +
+				"strictly increasing")
+		}
+	}
+
+	return Trajectory{
+		append([]TrajectoryPoint(nil), points...),
+	}, nil
+}
+
+// NewWaypointTrajectory returns a Trajectory starting at the origin and going
+// through the given waypoints, at their speeds. Returns the trajectory and a
+// nil error on success and an empty Trajectory and a non-nil error on failure.
+func NewWaypointTrajectory(waypoints ...Waypoint) (Trajectory, error) {
+	if len(waypoints) == 0 {
+		return Trajectory{}, fmt.Errorf("trajectory must have at least one " +
+			"waypoint")
+	}
+
+	points := []TrajectoryPoint{{}}
+	for _, waypoint := range waypoints {
+		if waypoint.Speed < 0 {
+			return Trajectory{}, fmt.Errorf("waypoint speed must not be " +
+				"negative")
+		}
+
+		speed := waypoint.Speed
+		if speed == 0 {
+			speed = defaultWaypointSpeed
+		}
+
+		previous := points[len(points)-1]
+
+		distance := math.Hypot(waypoint.X-previous.X, waypoint.Y-previous.Y)
+		rotation := math.Abs(normalizeAngle(waypoint.Yaw - previous.Yaw))
+
+		seconds := math.Max(distance/speed,
+			rotation/defaultWaypointRotationSpeed)
+		if seconds == 0 {
+			// Same pose as the previous one. Nothing to do.
+			continue
+		}
+
+		points = append(points, TrajectoryPoint{
+			Time: previous.Time + time.Duration(seconds*float64(time.Second)),
+			Pose: waypoint.Pose,
+		})
+	}
+
+	return NewTrajectory(points...)
+}
+
+// NewFigureEight returns a Trajectory that drives the chassis along a
+// figure-eight of the given size (in meters, along its long axis) once, in the
+// given period. The chassis starts at the crossing point heading forward and
+// its heading follows the path.
+func NewFigureEight(size float64, period time.Duration) Trajectory {
+	points := make([]TrajectoryPoint, 0, figureEightSamples+1)
+
+	// Lemniscate of Gerono, rotated so it starts heading forward.
+	a := size / 2
+	startHeading := math.Pi / 4
+	sin0, cos0 := math.Sincos(-startHeading)
+
+	for i := 0; i <= figureEightSamples; i++ {
+		theta := 2 * math.Pi * float64(i) / figureEightSamples
+
+		sin, cos := math.Sincos(theta)
+		x := a * sin
+		y := a * sin * cos
+		dx := a * cos
+		dy := a * (cos*cos - sin*sin)
+		heading := math.Atan2(dy, dx) - startHeading
+
+		points = append(points, TrajectoryPoint{
+			Time: time.Duration(float64(period) * float64(i) /
+				figureEightSamples),
+			Pose: Pose{
+				X:   x*cos0 - y*sin0,
+				Y:   x*sin0 + y*cos0,
+				Yaw: normalizeAngle(heading * 180 / math.Pi),
+			},
+		})
+	}
+
+	return Trajectory{points}
+}
+
+// Points returns the points of the trajectory.
+func (t Trajectory) Points() []TrajectoryPoint {
+	return append([]TrajectoryPoint(nil), t.points...)
+}
+
+// Duration returns the time it takes to follow the trajectory.
+func (t Trajectory) Duration() time.Duration {
+	if len(t.points) == 0 {
+		return 0
+	}
+
+	return t.points[len(t.points)-1].Time
+}
+
+// Sample returns the pose the chassis should be at the given time since the
+// start of the trajectory, the velocity needed to follow it at that time
+// (in the trajectory frame, not the body frame) and the index of the segment
+// being followed (the segment i goes from point i to point i+1).
+func (t Trajectory) Sample(at time.Duration) (Pose, Velocity, int) {
+	if len(t.points) == 0 {
+		return Pose{}, Velocity{}, 0
+	}
+
+	if at <= t.points[0].Time {
+		return t.points[0].Pose, Velocity{}, 0
+	}
+
+	last := len(t.points) - 1
+	if at >= t.points[last].Time {
+		segment := last - 1
+		if segment < 0 {
+			segment = 0
+		}
+
+		return t.points[last].Pose, Velocity{}, segment
+	}
+
+	i := 0
+	for at >= t.points[i+1].Time {
+		i++
+	}
+
+	from := t.points[i]
+	to := t.points[i+1]
+
+	seconds := (to.Time - from.Time).Seconds()
+	fraction := (at - from.Time).Seconds() / seconds
+
+	dx := to.X - from.X
+	dy := to.Y - from.Y
+	dyaw := normalizeAngle(to.Yaw - from.Yaw)
+
+	pose := Pose{
+		X:   from.X + dx*fraction,
+		Y:   from.Y + dy*fraction,
+		Yaw: normalizeAngle(from.Yaw + dyaw*fraction),
+	}
+
+	velocity := Velocity{
+		Vx: dx / seconds,
+		Vy: dy / seconds,
+		Wz: dyaw / seconds,
+	}
+
+	return pose, velocity, i
+}
+
+// crossTrackError returns the distance (in meters) from the given pose to the
+// line of the given segment. It is positive when the pose is to the right of
+// the segment direction.
+func (t Trajectory) crossTrackError(pose Pose, segment int) float64 {
+	if segment+1 >= len(t.points) {
+		if len(t.points) == 0 {
+			return 0
+		}
+
+		last := t.points[len(t.points)-1]
+
+		return math.Hypot(pose.X-last.X, pose.Y-last.Y)
+	}
+
+	from := t.points[segment]
+	to := t.points[segment+1]
+
+	dx := to.X - from.X
+	dy := to.Y - from.Y
+
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return math.Hypot(pose.X-from.X, pose.Y-from.Y)
+	}
+
+	// Y is to the right, so the cross product is positive to the right.
+	return (dx*(pose.Y-from.Y) - dy*(pose.X-from.X)) / length
+}
@@ -0,0 +1,236 @@
+package chassis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewTrajectory_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []TrajectoryPoint
+	}{
+		{"Empty", nil},
+		{"NegativeTime", []TrajectoryPoint{{Time: -time.Second}}},
+		{"SameTime", []TrajectoryPoint{{Time: time.Second},
+			{Time: time.Second}}},
+		{"DecreasingTime", []TrajectoryPoint{{Time: 2 * time.Second},
+			{Time: time.Second}}},
+	}
+
+	for _, test := range tests {
+		_, err := NewTrajectory(test.points...)
+		if err == nil {
+			t.Errorf("%s: expected non-nil error, got nil", test.name)
+		}
+	}
+}
+
+func TestTrajectory_Sample(t *testing.T) {
+	traj, err := NewTrajectory(
+		TrajectoryPoint{0, Pose{}},
+		TrajectoryPoint{2 * time.Second, Pose{2, 0, 0}},
+		TrajectoryPoint{3 * time.Second, Pose{2, 1, 90}},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	tests := []struct {
+		at       time.Duration
+		pose     Pose
+		velocity Velocity
+		segment  int
+	}{
+		{-time.Second, Pose{}, Velocity{}, 0},
+		{0, Pose{}, Velocity{}, 0},
+		{time.Second, Pose{1, 0, 0}, Velocity{Vx: 1}, 0},
+		{2 * time.Second, Pose{2, 0, 0}, Velocity{Vy: 1, Wz: 90}, 1},
+		{2500 * time.Millisecond, Pose{2, 0.5, 45},
+			Velocity{Vy: 1, Wz: 90}, 1},
+		{3 * time.Second, Pose{2, 1, 90}, Velocity{}, 1},
+		{time.Minute, Pose{2, 1, 90}, Velocity{}, 1},
+	}
+
+	for _, test := range tests {
+		pose, velocity, segment := traj.Sample(test.at)
+		if !poseAlmostEqual(pose, test.pose) {
+			t.Errorf("%s: expected pose %s, got %s", test.at, test.pose,
+				pose)
+		}
+		if !almostEqual(velocity.Vx, test.velocity.Vx) ||
+			!almostEqual(velocity.Vy, test.velocity.Vy) ||
+			!almostEqual(velocity.Wz, test.velocity.Wz) {
+			t.Errorf("%s: expected velocity %s, got %s", test.at,
+				test.velocity, velocity)
+		}
+		if segment != test.segment {
+			t.Errorf("%s: expected segment %d, got %d", test.at,
+				test.segment, segment)
+		}
+	}
+}
+
+func TestTrajectory_Sample_YawWrap(t *testing.T) {
+	traj, err := NewTrajectory(
+		TrajectoryPoint{0, Pose{Yaw: 170}},
+		TrajectoryPoint{time.Second, Pose{Yaw: -170}},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// Turns 20 degrees clockwise through 180, not 340 counterclockwise.
+	pose, velocity, _ := traj.Sample(500 * time.Millisecond)
+	if !almostEqual(pose.Yaw, 180) || !almostEqual(velocity.Wz, 20) {
+		t.Fatalf("expected yaw 180 at 20 deg/s, got %f at %f deg/s",
+			pose.Yaw, velocity.Wz)
+	}
+}
+
+func TestTrajectory_Sample_SinglePoint(t *testing.T) {
+	traj, err := NewTrajectory(TrajectoryPoint{0, Pose{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	pose, velocity, segment := traj.Sample(time.Second)
+	if !poseAlmostEqual(pose, Pose{1, 2, 3}) || velocity != (Velocity{}) ||
+		segment != 0 {
+		t.Fatalf("unexpected sample: %s, %s, %d", pose, velocity, segment)
+	}
+}
+
+func TestNewWaypointTrajectory(t *testing.T) {
+	traj, err := NewWaypointTrajectory(
+		Waypoint{Pose{1, 0, 0}, 0.5},
+		Waypoint{Pose{1, 0, 0}, 0}, // Same pose. Skipped.
+		Waypoint{Pose{1, 0, 90}, 0},
+		Waypoint{Pose{1, 2, 90}, 1},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	expected := []TrajectoryPoint{
+		{0, Pose{}},
+		{2 * time.Second, Pose{1, 0, 0}},
+		// Pure rotation at the default rotation speed.
+		{3 * time.Second, Pose{1, 0, 90}},
+		{5 * time.Second, Pose{1, 2, 90}},
+	}
+
+	points := traj.Points()
+	if len(points) != len(expected) {
+		t.Fatalf("expected %d points, got %d", len(expected), len(points))
+	}
+
+	for i := range expected {
+		if points[i].Time != expected[i].Time ||
+			!poseAlmostEqual(points[i].Pose, expected[i].Pose) {
+			t.Errorf("point %d: expected %+v, got %+v", i, expected[i],
+				points[i])
+		}
+	}
+
+	if traj.Duration() != 5*time.Second {
+		t.Fatalf("expected 5s duration, got %s", traj.Duration())
+	}
+}
+
+func TestNewWaypointTrajectory_Errors(t *testing.T) {
+	_, err := NewWaypointTrajectory()
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	_, err = NewWaypointTrajectory(Waypoint{Pose{1, 0, 0}, -1})
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	// All waypoints at the origin leave a single point.
+	traj, err := NewWaypointTrajectory(Waypoint{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if len(traj.Points()) != 1 || traj.Duration() != 0 {
+		t.Fatalf("expected single point trajectory, got %+v", traj.Points())
+	}
+}
+
+func TestNewFigureEight(t *testing.T) {
+	size := 2.0
+	period := 8 * time.Second
+
+	traj := NewFigureEight(size, period)
+
+	points := traj.Points()
+	if len(points) != figureEightSamples+1 {
+		t.Fatalf("expected %d points, got %d", figureEightSamples+1,
+			len(points))
+	}
+
+	if traj.Duration() != period {
+		t.Fatalf("expected %s duration, got %s", period, traj.Duration())
+	}
+
+	const tolerance = 1e-6
+
+	// Starts and ends at the origin heading forward.
+	for _, point := range []TrajectoryPoint{points[0],
+		points[len(points)-1]} {
+		if math.Hypot(point.X, point.Y) > tolerance ||
+			math.Abs(point.Yaw) > tolerance {
+			t.Fatalf("expected origin heading forward, got %s", point.Pose)
+		}
+	}
+
+	// Crosses the origin again halfway.
+	pose, _, _ := traj.Sample(period / 2)
+	if math.Hypot(pose.X, pose.Y) > tolerance {
+		t.Fatalf("expected crossing at the origin, got %s", pose)
+	}
+
+	// Stays within the requested size.
+	for _, point := range points {
+		if math.Hypot(point.X, point.Y) > size/2+tolerance {
+			t.Fatalf("expected points within %f m, got %s", size/2,
+				point.Pose)
+		}
+	}
+}
+
+func TestTrajectory_CrossTrackError(t *testing.T) {
+	traj, err := NewTrajectory(
+		TrajectoryPoint{0, Pose{}},
+		TrajectoryPoint{time.Second, Pose{2, 0, 0}},
+		TrajectoryPoint{2 * time.Second, Pose{2, 0, 90}},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	tests := []struct {
+		name     string
+		pose     Pose
+		segment  int
+		expected float64
+	}{
+		{"OnLine", Pose{1, 0, 0}, 0, 0},
+		{"Right", Pose{1, 0.5, 0}, 0, 0.5},
+		{"Left", Pose{1, -0.25, 0}, 0, -0.25},
+		{"BeyondEnd", Pose{3, 1, 0}, 0, 1},
+		{"ZeroLengthSegment", Pose{2, 3, 0}, 1, 3},
+		{"PastLastSegment", Pose{5, 4, 0}, 2, 5},
+	}
+
+	for _, test := range tests {
+		got := traj.crossTrackError(test.pose, test.segment)
+		if !almostEqual(got, test.expected) {
+			t.Errorf("%s: expected %f, got %f", test.name, test.expected,
+				got)
+		}
+	}
+}
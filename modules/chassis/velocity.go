@@ -0,0 +1,146 @@
+package chassis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/brunoga/robomaster2/modules/chassis/kinematics"
+	"github.com/brunoga/robomaster2/modules/robot/virtualstick"
+)
+
+var (
+	// Nominal S1/EP limits, used until the actual ones are read from the
+	// robot.
+	defaultSpeedLimits = SpeedLimits{
+		Forward:  3.5,
+		Backward: 2.5,
+		Lateral:  2.8,
+		Rotation: 600,
+	}
+)
+
+// SpeedLimits holds the maximum chassis speeds. Forward, Backward and Lateral
+// are in m/s and Rotation is in degrees/s. They correspond to full stick
+// deflection.
+type SpeedLimits struct {
+	Forward  float64
+	Backward float64
+	Lateral  float64
+	Rotation float64
+}
+
+// Velocity represents a chassis velocity in the robot body frame. Vx is the
+// forward speed and Vy is the speed to the right, both in m/s. Wz is the
+// clockwise rotation speed in degrees/s.
+type Velocity struct {
+	Vx float64
+	Vy float64
+	Wz float64
+}
+
+func (v Velocity) String() string {
+	return fmt.Sprintf("Vx:%.3f m/s, Vy:%.3f m/s, Wz:%.1f deg/s", v.Vx, v.Vy,
+		v.Wz)
+}
+
+// SpeedLimits returns the maximum chassis speeds currently in use.
+func (c *Chassis) SpeedLimits() SpeedLimits {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.limits
+}
+
+// Geometry returns the physical model of the chassis.
+func (c *Chassis) Geometry() kinematics.Geometry {
+	return c.geometry
+}
+
+// SetVelocity sets the chassis velocity. vx is the forward speed and vy is the
+// speed to the right, both in m/s. wz is the clockwise rotation speed in
+// degrees/s. Velocities above the speed limits or that would require wheels to
+// go above their maximum speed are scaled down preserving the direction of
+// movement. The velocity is continuously sent to the robot by its virtual
+// stick sender until it is changed, Brake is called, the chassis is stopped or
+// the robot deadman timeout expires (see robot.Robot.SetDeadman). Callers must
+// keep calling SetVelocity to keep the chassis moving for longer than that.
+func (c *Chassis) SetVelocity(vx, vy, wz float64) {
+	velocity := Velocity{vx, vy, wz}
+
+	// The velocity is stored and sent under the same lock so Velocity never
+	// sees it with sticks that are still centered.
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.velocity = velocity
+
+	c.robot.SetSticks(velocityToSticksState(velocity, c.limits, c.geometry))
+}
+
+// Velocity returns the chassis velocity last set with SetVelocity. It is
+// reported as zero once the robot centered the sticks (for example, after the
+// deadman timeout expired, see robot.Robot.SetDeadman).
+func (c *Chassis) Velocity() Velocity {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	sticks := c.robot.Sticks()
+	if sticks.LeftVertical == 0 && sticks.LeftHorizontal == 0 &&
+		sticks.RightHorizontal == 0 {
+		c.velocity = Velocity{}
+	}
+
+	return c.velocity
+}
+
+// Brake sets the chassis velocity to zero.
+func (c *Chassis) Brake() {
+	c.SetVelocity(0, 0, 0)
+}
+
+// velocityToSticksState returns the virtual stick state that drives the
+// chassis with the given velocity.
+func velocityToSticksState(velocity Velocity, limits SpeedLimits,
+	geometry kinematics.Geometry) virtualstick.Sticks {
+	// Combined translation and rotation might be too much for the wheels even
+	// if each is within its own limit.
+	twist := geometry.Limit(kinematics.Twist{
+		Vx: velocity.Vx,
+		Vy: velocity.Vy,
+		Wz: velocity.Wz,
+	})
+
+	forward, right, clockwise := velocityToSticks(
+		Velocity{twist.Vx, twist.Vy, twist.Wz}, limits)
+
+	return virtualstick.Sticks{
+		LeftVertical:    float32(forward),
+		LeftHorizontal:  float32(right),
+		RightHorizontal: float32(clockwise),
+		LeftTouched:     forward != 0 || right != 0,
+		RightTouched:    clockwise != 0,
+	}
+}
+
+// velocityToSticks converts the given velocity to stick deflections in the
+// [-1, 1] range, given the speed limits. Translation is scaled down as a whole
+// when above the limits so its direction is preserved.
+func velocityToSticks(velocity Velocity,
+	limits SpeedLimits) (forward, right, clockwise float64) {
+	if velocity.Vx >= 0 {
+		forward = velocity.Vx / limits.Forward
+	} else {
+		forward = velocity.Vx / limits.Backward
+	}
+
+	right = velocity.Vy / limits.Lateral
+
+	if scale := math.Max(math.Abs(forward), math.Abs(right)); scale > 1 {
+		forward /= scale
+		right /= scale
+	}
+
+	clockwise = math.Max(-1, math.Min(1, velocity.Wz/limits.Rotation))
+
+	return forward, right, clockwise
+}
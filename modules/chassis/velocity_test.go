@@ -0,0 +1,77 @@
+package chassis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+	"github.com/brunoga/robomaster2/modules/robot"
+	"github.com/brunoga/robomaster2/support"
+)
+
+func TestVelocityToSticks(t *testing.T) {
+	limits := SpeedLimits{
+		Forward:  2,
+		Backward: 1,
+		Lateral:  1,
+		Rotation: 100,
+	}
+
+	tests := []struct {
+		name      string
+		velocity  Velocity
+		forward   float64
+		right     float64
+		clockwise float64
+	}{
+		{"Zero", Velocity{}, 0, 0, 0},
+		{"Forward", Velocity{Vx: 1}, 0.5, 0, 0},
+		{"Backward", Velocity{Vx: -0.5}, -0.5, 0, 0},
+		{"Right", Velocity{Vy: 0.5}, 0, 0.5, 0},
+		{"Left", Velocity{Vy: -0.5}, 0, -0.5, 0},
+		{"Clockwise", Velocity{Wz: 50}, 0, 0, 0.5},
+		{"CounterClockwise", Velocity{Wz: -50}, 0, 0, -0.5},
+		{"FullForward", Velocity{Vx: 2}, 1, 0, 0},
+		{"ScaledForward", Velocity{Vx: 4, Vy: 1}, 1, 0.5, 0},
+		{"ScaledBackward", Velocity{Vx: -2, Vy: -1}, -1, -0.5, 0},
+		{"ScaledLateral", Velocity{Vx: 1, Vy: -2}, 0.25, -1, 0},
+		{"ClampedClockwise", Velocity{Wz: 300}, 0, 0, 1},
+		{"ClampedCounterClockwise", Velocity{Wz: -300}, 0, 0, -1},
+		{"RotationNotScaled", Velocity{Vx: 4, Wz: 50}, 1, 0, 0.5},
+	}
+
+	for _, test := range tests {
+		forward, right, clockwise := velocityToSticks(test.velocity, limits)
+		if !almostEqual(forward, test.forward) ||
+			!almostEqual(right, test.right) ||
+			!almostEqual(clockwise, test.clockwise) {
+			t.Errorf("%s: expected (%.3f, %.3f, %.3f), got (%.3f, %.3f, "+
+				"%.3f)", test.name, test.forward, test.right,
+				test.clockwise, forward, right, clockwise)
+		}
+	}
+}
+
+func TestChassis_Velocity_Deadman(t *testing.T) {
+	servicetest.Install(t)
+
+	r := robot.NewRobot(support.NewLogger(nil, nil, nil, nil))
+	r.SetDeadman(50 * time.Millisecond)
+	defer r.Stop()
+
+	c := New(support.NewLogger(nil, nil, nil, nil), r)
+
+	c.SetVelocity(1, 0, 0)
+
+	velocity := c.Velocity()
+	if velocity != (Velocity{Vx: 1}) {
+		t.Fatalf("expected velocity %s, got %s", Velocity{Vx: 1}, velocity)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	velocity = c.Velocity()
+	if velocity != (Velocity{}) {
+		t.Fatalf("expected zero velocity, got %s", velocity)
+	}
+}
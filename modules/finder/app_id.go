@@ -0,0 +1,161 @@
+package finder
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	configDirName    = "robomaster2"
+	appIDFileName    = "app_id"
+	unpairedFileName = "unpaired"
+)
+
+// DefaultAppIDPath returns the path of the file where the app ID used to pair
+// with robots is persisted by default. Returns the path and a nil error on
+// success and an empty string and a non-nil error on failure.
+func DefaultAppIDPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, appIDFileName), nil
+}
+
+// LoadOrCreateAppID loads the app ID persisted at the given path. If there is
+// no app ID persisted yet, a new random one is generated and persisted. Returns
+// the app ID and a nil error on success and 0 and a non-nil error on failure.
+func LoadOrCreateAppID(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		appID, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10,
+			64)
+		if err != nil || appID == 0 {
+			return 0, fmt.Errorf("invalid app ID in %q", path)
+		}
+
+		return appID, nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("error reading app ID: %w", err)
+	}
+
+	appID, err := newAppID()
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return 0, fmt.Errorf("error creating app ID directory: %w", err)
+	}
+
+	err = os.WriteFile(path, []byte(strconv.FormatUint(appID, 10)+"\n"),
+		0644)
+	if err != nil {
+		return 0, fmt.Errorf("error writing app ID: %w", err)
+	}
+
+	return appID, nil
+}
+
+// LoadUnpaired loads the MAC addresses of explicitly unpaired robots persisted
+// at the given path. A missing file means no robot was unpaired. Returns the
+// MAC addresses and a nil error on success and nil and a non-nil error on
+// failure.
+func LoadUnpaired(path string) ([]net.HardwareAddr, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading unpaired robots: %w", err)
+	}
+
+	var macs []net.HardwareAddr
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		mac, err := net.ParseMAC(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unpaired robot in %q: %w", path,
+				err)
+		}
+
+		macs = append(macs, mac)
+	}
+
+	return macs, nil
+}
+
+// SaveUnpaired persists the given MAC addresses of explicitly unpaired robots
+// at the given path, one per line. Returns a nil error on success and a
+// non-nil error on failure.
+func SaveUnpaired(path string, macs []net.HardwareAddr) error {
+	lines := make([]string, 0, len(macs))
+	for _, mac := range macs {
+		lines = append(lines, mac.String()+"\n")
+	}
+
+	sort.Strings(lines)
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating unpaired robots directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failure never leaves a truncated
+	// file behind.
+	tmpPath := path + ".tmp"
+
+	err = os.WriteFile(tmpPath, []byte(strings.Join(lines, "")), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing unpaired robots: %w", err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return fmt.Errorf("error writing unpaired robots: %w", err)
+	}
+
+	return nil
+}
+
+func newAppID() (uint64, error) {
+	buf := make([]byte, 8)
+	for {
+		_, err := rand.Read(buf)
+		if err != nil {
+			return 0, fmt.Errorf("error generating app ID: %w", err)
+		}
+
+		// 0 means "no app ID", so make sure we never use it.
+		if appID := binary.LittleEndian.Uint64(buf); appID != 0 {
+			return appID, nil
+		}
+	}
+}
+
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, configDirName), nil
+}
@@ -0,0 +1,84 @@
+package finder
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadOrCreateAppID_Success(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", appIDFileName)
+
+	appID, err := LoadOrCreateAppID(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if appID == 0 {
+		t.Fatalf("expected non-zero app ID")
+	}
+
+	appID2, err := LoadOrCreateAppID(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if appID2 != appID {
+		t.Fatalf("expected persisted app ID %d, got %d", appID, appID2)
+	}
+}
+
+func TestLoadOrCreateAppID_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), appIDFileName)
+
+	err := os.WriteFile(path, []byte("not a number"), 0644)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	_, err = LoadOrCreateAppID(path)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
+
+func TestLoadSaveUnpaired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", unpairedFileName)
+
+	macs, err := LoadUnpaired(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if len(macs) != 0 {
+		t.Fatalf("expected no unpaired robots, got %v", macs)
+	}
+
+	expected := []net.HardwareAddr{
+		{1, 2, 3, 4, 5, 6},
+		{1, 2, 3, 4, 5, 7},
+	}
+
+	err = SaveUnpaired(path, []net.HardwareAddr{expected[1], expected[0]})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	macs, err = LoadUnpaired(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(macs, expected) {
+		t.Fatalf("expected %v, got %v", expected, macs)
+	}
+
+	err = os.WriteFile(path, []byte("not a mac\n"), 0644)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	_, err = LoadUnpaired(path)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
@@ -0,0 +1,238 @@
+// Package beacon provides a synthetic robot beacon. It broadcasts robot
+// information in the same format used by actual robots and records the ACKs
+// it receives, behaving like a robot would during pairing. This allows testing
+// robot discovery and pairing logic without an actual robot.
+package beacon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster2/modules/finder"
+)
+
+const (
+	defaultInterval = 100 * time.Millisecond
+)
+
+// ACK represents an ACK received by a Beacon.
+type ACK struct {
+	Source   net.Addr
+	AppID    uint64
+	Received time.Time
+}
+
+// Beacon broadcasts the information of a synthetic robot.
+type Beacon struct {
+	m           sync.Mutex
+	robot       finder.Robot
+	broadcastIP net.IP
+	interval    time.Duration
+	autoPair    bool
+	acks        []ACK
+
+	broadcastConn *net.UDPConn
+	ackConn       net.PacketConn
+	quitChan      chan struct{}
+	wg            sync.WaitGroup
+}
+
+// New returns a new Beacon instance that will broadcast the IP, MAC, AppID and
+// IsPairing information of the given robot. Broadcasts are sent from the robot
+// IP (which must be a local address, usually a loopback one like 127.0.0.1)
+// to 127.0.0.1 by default. When an ACK is received, the beacon switches to the
+// app ID in it and leaves pairing mode (like an actual robot does).
+func New(robot finder.Robot) *Beacon {
+	return &Beacon{
+		robot:       robot,
+		broadcastIP: net.IPv4(127, 0, 0, 1),
+		interval:    defaultInterval,
+		autoPair:    true,
+	}
+}
+
+// SetBroadcastIP sets the IP broadcasts are sent to. Must be called before
+// Start.
+func (b *Beacon) SetBroadcastIP(ip net.IP) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.broadcastIP = ip
+}
+
+// SetInterval sets the interval between broadcasts. Must be called before
+// Start.
+func (b *Beacon) SetInterval(interval time.Duration) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.interval = interval
+}
+
+// SetAutoPair sets if the beacon should switch to the app ID in the ACKs it
+// receives and leave pairing mode.
+func (b *Beacon) SetAutoPair(autoPair bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.autoPair = autoPair
+}
+
+// SetAppID sets the app ID being broadcast.
+func (b *Beacon) SetAppID(appID uint64) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.robot.AppID = appID
+}
+
+// SetPairing sets if the beacon is broadcasting in pairing mode.
+func (b *Beacon) SetPairing(isPairing bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.robot.IsPairing = isPairing
+}
+
+// Robot returns the robot information currently being broadcast.
+func (b *Beacon) Robot() finder.Robot {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return b.robot
+}
+
+// Start starts broadcasting to the given port and listening for ACKs on the
+// given port (0 means any available port, see ACKPort). Returns a nil error on
+// success and a non-nil error on failure.
+func (b *Beacon) Start(broadcastPort, ackPort int) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.quitChan != nil {
+		return fmt.Errorf("beacon already started")
+	}
+
+	ackConn, err := net.ListenPacket("udp4", (&net.UDPAddr{
+		IP: b.robot.IP, Port: ackPort}).String())
+	if err != nil {
+		return fmt.Errorf("error listening for ACKs: %w", err)
+	}
+
+	broadcastConn, err := net.DialUDP("udp4", &net.UDPAddr{IP: b.robot.IP},
+		&net.UDPAddr{IP: b.broadcastIP, Port: broadcastPort})
+	if err != nil {
+		ackConn.Close()
+		return fmt.Errorf("error dialing UDP: %w", err)
+	}
+
+	b.ackConn = ackConn
+	b.broadcastConn = broadcastConn
+	b.quitChan = make(chan struct{})
+
+	b.wg.Add(2)
+	go b.broadcastLoop(b.quitChan, b.interval)
+	go b.ackLoop()
+
+	return nil
+}
+
+// Stop stops broadcasting and listening for ACKs.
+func (b *Beacon) Stop() {
+	b.m.Lock()
+	if b.quitChan == nil {
+		b.m.Unlock()
+		return
+	}
+
+	close(b.quitChan)
+	b.quitChan = nil
+
+	b.ackConn.Close()
+	b.broadcastConn.Close()
+	b.m.Unlock()
+
+	b.wg.Wait()
+}
+
+// ACKPort returns the port the beacon is listening for ACKs on or 0 if it was
+// not started.
+func (b *Beacon) ACKPort() int {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.ackConn == nil {
+		return 0
+	}
+
+	return b.ackConn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// ACKs returns all ACKs received so far.
+func (b *Beacon) ACKs() []ACK {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return append([]ACK(nil), b.acks...)
+}
+
+func (b *Beacon) broadcastLoop(quitChan chan struct{}, interval time.Duration) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		robot := b.Robot()
+
+		data, err := finder.NewBroadcastMessage(robot.IsPairing, robot.IP,
+			robot.MAC, robot.AppID).MarshalBinary()
+		if err != nil {
+			// Invalid robot information. Nothing we can broadcast.
+			return
+		}
+
+		// Errors are ignored as nobody might be listening yet.
+		b.broadcastConn.Write(data)
+
+		select {
+		case <-ticker.C:
+		case <-quitChan:
+			return
+		}
+	}
+}
+
+func (b *Beacon) ackLoop() {
+	defer b.wg.Done()
+
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := b.ackConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if n != 8 {
+			// Not an ACK.
+			continue
+		}
+
+		ack := ACK{
+			Source:   addr,
+			AppID:    binary.LittleEndian.Uint64(buf[:n]),
+			Received: time.Now(),
+		}
+
+		b.m.Lock()
+		b.acks = append(b.acks, ack)
+		if b.autoPair {
+			b.robot.AppID = ack.AppID
+			b.robot.IsPairing = false
+		}
+		b.m.Unlock()
+	}
+}
@@ -0,0 +1,344 @@
+package beacon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/modules/finder"
+	"github.com/brunoga/robomaster2/support"
+)
+
+func freeUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func startTestBeacon(t *testing.T, broadcastPort int, mac net.HardwareAddr,
+	appID uint64, isPairing bool) *Beacon {
+	b := New(finder.Robot{
+		IP:        net.IPv4(127, 0, 0, 1),
+		MAC:       mac,
+		AppID:     appID,
+		IsPairing: isPairing,
+	})
+	b.SetInterval(20 * time.Millisecond)
+
+	err := b.Start(broadcastPort, 0)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	t.Cleanup(b.Stop)
+
+	return b
+}
+
+func newTestFinder(broadcastPort, ackPort int) *finder.Finder {
+	f := finder.New(support.NewLogger(nil, nil, nil, nil))
+	f.SetAppID(5678)
+	f.SetPorts(broadcastPort, ackPort)
+
+	return f
+}
+
+func TestBeacon_Discover_MultipleRobots(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+
+	startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6}, 1,
+		false)
+	startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 7}, 2,
+		true)
+
+	f := newTestFinder(broadcastPort, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	robotChan, err := f.Discover(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	seen := make(map[string]bool)
+	for robot := range robotChan {
+		seen[robot.MAC.String()] = true
+		if len(seen) == 2 {
+			break
+		}
+	}
+	cancel()
+
+	if len(seen) != 2 {
+		t.Fatalf("expected 2 robots, got %d", len(seen))
+	}
+	if len(f.Robots()) != 2 {
+		t.Fatalf("expected 2 robots, got %d", len(f.Robots()))
+	}
+}
+
+func TestBeacon_Pair_Success(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, true)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	ip, err := f.GetOrFindIP(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if !ip.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("expected 127.0.0.1, got %s", ip)
+	}
+
+	if b.Robot().AppID != 5678 {
+		t.Fatalf("expected beacon app ID to be 5678, got %d",
+			b.Robot().AppID)
+	}
+
+	acks := b.ACKs()
+	if len(acks) == 0 {
+		t.Fatalf("expected ACKs, got none")
+	}
+	if acks[0].AppID != 5678 {
+		t.Fatalf("expected ACK with app ID 5678, got %d", acks[0].AppID)
+	}
+}
+
+func TestBeacon_Pair_BoundToInterface(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	loopback := ""
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			loopback = iface.Name
+			break
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface")
+	}
+
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, true)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	err = f.SetInterface(loopback)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	_, err = f.GetOrFindIP(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// Pairing ACKs are sent from the discovery socket.
+	acks := b.ACKs()
+	if len(acks) == 0 {
+		t.Fatalf("expected ACKs, got none")
+	}
+	if port := acks[0].Source.(*net.UDPAddr).Port; port != broadcastPort {
+		t.Fatalf("expected ACK from port %d, got %d", broadcastPort, port)
+	}
+
+	// Without a discovery running, a new socket bound the same way is used.
+	f.SendACK()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(b.ACKs()) == len(acks) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a new ACK, got none")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBeacon_GetOrFindIP_Concurrent(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, true)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	errChan := make(chan error, 3)
+	for i := 0; i < cap(errChan); i++ {
+		go func() {
+			_, err := f.GetOrFindIP(5 * time.Second)
+			errChan <- err
+		}()
+	}
+
+	for i := 0; i < cap(errChan); i++ {
+		err := <-errChan
+		if err != nil {
+			t.Fatalf("expected nil error, got %q", err)
+		}
+	}
+}
+
+func TestBeacon_Discover_Concurrent(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6}, 1,
+		false)
+
+	f := newTestFinder(broadcastPort, 0)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+
+	robotChan1, err := f.Discover(ctx1)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// A second discovery waits for the first one to stop.
+	ctx2, cancel2 := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel2()
+
+	_, err = f.Discover(ctx2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	cancel1()
+	for range robotChan1 {
+	}
+
+	ctx3, cancel3 := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel3()
+
+	robot, err := f.WaitForRobot(ctx3, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if robot.AppID != 1 {
+		t.Fatalf("expected app ID 1, got %d", robot.AppID)
+	}
+}
+
+func TestBeacon_GetOrFindIP_PrefersKnownRobots(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	unknown := startTestBeacon(t, broadcastPort,
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 1234, true)
+	known := startTestBeacon(t, broadcastPort,
+		net.HardwareAddr{1, 2, 3, 4, 5, 7}, 5678, false)
+
+	r, err := finder.LoadRegistry(filepath.Join(t.TempDir(), "robots.json"))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	r.Update(finder.KnownRobot{MAC: known.Robot().MAC.String()})
+
+	f := newTestFinder(broadcastPort, unknown.ACKPort())
+	f.SetRegistry(r)
+
+	_, err = f.GetOrFindIP(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// The robot in pairing mode was not paired with.
+	if len(unknown.ACKs()) != 0 {
+		t.Fatalf("expected no ACKs, got %d", len(unknown.ACKs()))
+	}
+}
+
+func TestBeacon_Pair_PairedBefore(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		5678, false)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	_, err := f.GetOrFindIP(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+}
+
+func TestBeacon_Pair_NotPairing(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, false)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	robot, err := f.WaitForRobot(ctx, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	err = f.Pair(ctx, robot)
+	if !errors.Is(err, finder.ErrNotPairing) {
+		t.Fatalf("expected ErrNotPairing, got %v", err)
+	}
+
+	_, err = f.GetOrFindIP(200 * time.Millisecond)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
+
+func TestBeacon_Pair_NoConfirmation(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, true)
+	b.SetAutoPair(false)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	_, err := f.GetOrFindIP(500 * time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if len(b.ACKs()) == 0 {
+		t.Fatalf("expected ACKs, got none")
+	}
+}
+
+func TestBeacon_Pair_ACKRate(t *testing.T) {
+	broadcastPort := freeUDPPort(t)
+	b := startTestBeacon(t, broadcastPort, net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		1234, true)
+	b.SetInterval(5 * time.Millisecond)
+	b.SetAutoPair(false)
+
+	f := newTestFinder(broadcastPort, b.ACKPort())
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		700*time.Millisecond)
+	defer cancel()
+
+	err := f.Pair(ctx, b.Robot())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// One ACK right away and one per pairing ACK interval, no matter how
+	// many broadcasts were received.
+	acks := len(b.ACKs())
+	if acks < 1 || acks > 2 {
+		t.Fatalf("expected 1 or 2 ACKs, got %d", acks)
+	}
+}
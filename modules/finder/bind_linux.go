@@ -0,0 +1,23 @@
+//go:build linux
+
+package finder
+
+import (
+	"syscall"
+)
+
+// bindToInterface restricts the socket behind the given connection to the
+// network interface with the given name, so packets are only received from
+// and sent through it.
+func bindToInterface(c syscall.RawConn, name string) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET,
+			syscall.SO_BINDTODEVICE, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	return sockErr
+}
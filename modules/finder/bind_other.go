@@ -0,0 +1,14 @@
+//go:build !linux
+
+package finder
+
+import (
+	"syscall"
+)
+
+// bindToInterface does nothing on this platform. Robots are still restricted
+// to the interface networks and ACKs are sent from the interface address, but
+// the routing table decides which interface packets leave through.
+func bindToInterface(c syscall.RawConn, name string) error {
+	return nil
+}
@@ -25,6 +25,18 @@ type BroadcastMessage struct {
 	appId     uint64
 }
 
+// NewBroadcastMessage returns a BroadcastMessage instance with the given
+// information.
+func NewBroadcastMessage(isPairing bool, sourceIp net.IP,
+	sourceMac net.HardwareAddr, appId uint64) *BroadcastMessage {
+	return &BroadcastMessage{
+		isPairing,
+		sourceIp,
+		sourceMac,
+		appId,
+	}
+}
+
 // ParseBroadcastMessageData parses the given data as a BroadcastMessage. It
 // returns the associated BroadcastMessage instance pointer and a nil error on
 // success and a nil BroadcastMessage and a non-nil error on failure.
@@ -43,9 +55,10 @@ func ParseBroadcastMessageData(data []byte) (*BroadcastMessage, error) {
 	// First byte tells us if this is a pairing message.
 	isPairing := (data[2] & 1) > 0
 
-	// Then we get the rest of the data trivially.
-	sourceIp := data[6:10]
-	sourceMac := data[10:16]
+	// Then we get the rest of the data trivially. IP and MAC are copied so
+	// the message does not alias the given data.
+	sourceIp := append(net.IP(nil), data[6:10]...)
+	sourceMac := append(net.HardwareAddr(nil), data[10:16]...)
 	appId := binary.LittleEndian.Uint64(data[16:])
 
 	return &BroadcastMessage{
@@ -56,6 +69,35 @@ func ParseBroadcastMessageData(data []byte) (*BroadcastMessage, error) {
 	}, nil
 }
 
+// MarshalBinary encodes the BroadcastMessage in the same (obfuscated) format
+// used by robots. Returns the encoded data and a nil error on success and nil
+// and a non-nil error on failure.
+func (b *BroadcastMessage) MarshalBinary() ([]byte, error) {
+	ip := b.sourceIp.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("not an IPv4 address")
+	}
+
+	if len(b.sourceMac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address length")
+	}
+
+	data := make([]byte, broadcastMessageLen)
+
+	copy(data, broadcastMessageHeader)
+	if b.isPairing {
+		data[2] = 1
+	}
+	copy(data[6:10], ip)
+	copy(data[10:16], b.sourceMac)
+	binary.LittleEndian.PutUint64(data[16:], b.appId)
+
+	// Encode outgoing data.
+	support.SimpleEncryptDecrypt(data)
+
+	return data, nil
+}
+
 func (b *BroadcastMessage) IsPairing() bool {
 	return b.isPairing
 }
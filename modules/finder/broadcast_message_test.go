@@ -0,0 +1,45 @@
+package finder
+
+import (
+	"net"
+	"testing"
+)
+
+func TestBroadcastMessage_MarshalBinary_RoundTrip(t *testing.T) {
+	ip := net.IPv4(192, 168, 2, 1)
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	data, err := NewBroadcastMessage(true, ip, mac, 1234).MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if len(data) != broadcastMessageLen {
+		t.Fatalf("expected %d bytes, got %d", broadcastMessageLen, len(data))
+	}
+
+	b, err := ParseBroadcastMessageData(data)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if !b.IsPairing() || !b.SourceIp().Equal(ip) ||
+		b.SourceMac().String() != mac.String() || b.AppId() != 1234 {
+		t.Fatalf("unexpected broadcast message: %s", b)
+	}
+}
+
+func TestBroadcastMessage_MarshalBinary_Errors(t *testing.T) {
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	_, err := NewBroadcastMessage(false, net.ParseIP("::1"), mac,
+		0).MarshalBinary() // Not IPv4.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	_, err = NewBroadcastMessage(false, net.IPv4(192, 168, 2, 1),
+		mac[:4], 0).MarshalBinary() // Invalid MAC.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
@@ -1,9 +1,13 @@
 package finder
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,17 +15,49 @@ import (
 )
 
 const (
-	ipBroadcastAddrPort = ":45678"
-	listenerRemotePort  = ":56789"
+	ipBroadcastPort    = 45678
+	listenerRemotePort = 56789
+
+	// Robots broadcast about once per second so, by default, we consider a
+	// robot gone after missing several broadcasts in a row.
+	defaultRobotExpiration = 10 * time.Second
+
+	// By default, how long to wait for a known robot before considering any
+	// robot. Long enough for one broadcast.
+	defaultKnownRobotWait = 1200 * time.Millisecond
 )
 
-// Finder provides an interface for finding a robot broadcasting its ip in
+// Finder provides an interface for finding robots broadcasting their ips in
 // the network.
 type Finder struct {
-	l           *support.Logger
-	m           sync.Mutex
-	ip          net.IP
-	remoteAppID uint64
+	l     *support.Logger
+	m     sync.Mutex
+	ip    net.IP
+	mac   net.HardwareAddr
+	appID uint64
+
+	broadcastPort int
+	ackPort       int
+	iface         string
+	localIP       net.IP
+	localNets     []*net.IPNet
+	discoveryConn net.PacketConn
+
+	expiration    time.Duration
+	robotsByMAC   map[string]Robot
+	serialsByMAC  map[string]string
+	unpairedByMAC map[string]bool
+	unpairedPath  string
+	registry      *Registry
+
+	knownRobotWait time.Duration
+
+	// Serializes GetOrFindIP calls.
+	findM sync.Mutex
+
+	// Held while a discovery is running. All discoveries use the same
+	// broadcast port, so only one can run at a time.
+	discoverySem chan struct{}
 }
 
 // New returns a Finder instance with no associated ip.
@@ -30,31 +66,66 @@ func New(l *support.Logger) *Finder {
 		l,
 		sync.Mutex{},
 		nil,
+		nil,
 		0,
+		ipBroadcastPort,
+		listenerRemotePort,
+		"",
+		nil,
+		nil,
+		nil,
+		defaultRobotExpiration,
+		make(map[string]Robot),
+		make(map[string]string),
+		make(map[string]bool),
+		"",
+		nil,
+		defaultKnownRobotWait,
+		sync.Mutex{},
+		make(chan struct{}, 1),
 	}
 }
 
 // GetOrFindIP returns the ip of a robot if it is already know or tries to
-// detect a robot broadcasting its ip in the network. The search will go on
-// until a robot is detected or a timeout happens. Returns the robot ip and a
-// nil error on success and a non-nil error on failure.
+// detect a robot we can bind to (see IsBindable) in the network and pairs with
+// it. Robots in the registry (see SetRegistry) are preferred: a known robot
+// recently seen at its last known ip is used right away, otherwise known
+// robots are waited for a short time (see SetKnownRobotWait) before any robot
+// is considered. The search will go on until a robot is detected and paired or
+// a timeout happens. Returns the robot ip and a nil error on success and a non-nil error
+// on failure. Concurrent calls are serialized, so only the first one actually
+// searches for a robot.
 func (f *Finder) GetOrFindIP(timeout time.Duration) (net.IP, error) {
+	f.findM.Lock()
+	defer f.findM.Unlock()
+
 	f.m.Lock()
-	defer f.m.Unlock()
+	ip := f.ip
+	f.m.Unlock()
+
+	if ip != nil {
+		return ip, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	if f.ip == nil {
-		ip, remoteAppID, err := findRobotIP(timeout)
+	robot, err := f.findKnownRobot(ctx)
+	if err != nil {
+		robot, err = f.WaitForRobot(ctx, f.IsBindable)
 		if err != nil {
 			return nil, fmt.Errorf("error finding robot ip: %w", err)
 		}
+	}
 
-		f.l.INFO("Detected robot with ip %s", ip.String())
+	f.l.INFO("Detected robot with ip %s", robot.IP.String())
 
-		f.ip = ip
-		f.remoteAppID = remoteAppID
+	err = f.Pair(ctx, robot)
+	if err != nil {
+		return nil, err
 	}
 
-	return f.ip, nil
+	return robot.IP, nil
 }
 
 // SetIP forces the associated ip to be the given one. Useful for when
@@ -64,82 +135,380 @@ func (f *Finder) SetIP(ip net.IP) {
 	defer f.m.Unlock()
 
 	f.ip = ip
+	f.mac = nil
 }
 
-func (f *Finder) SendACK() {
-	f.l.INFO("Sending ACK to %s%s.\n", f.ip.String(), listenerRemotePort)
+// Select makes the given robot the one associated with this Finder (i.e. the
+// one returned by GetOrFindIP and the one SendACK talks to).
+func (f *Finder) Select(robot Robot) {
+	f.m.Lock()
+	defer f.m.Unlock()
 
-	buffer := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buffer, f.remoteAppID)
+	f.ip = robot.IP
+	f.mac = robot.MAC
+}
+
+// SetPorts sets the ports used to listen for robot broadcasts and to send ACKs
+// to robots. Useful for testing with a robot stand-in.
+func (f *Finder) SetPorts(broadcastPort, ackPort int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.broadcastPort = broadcastPort
+	f.ackPort = ackPort
+}
+
+// SetKnownRobotWait sets for how long GetOrFindIP waits for a robot in the
+// registry before considering any robot. A zero wait only considers known
+// robots that were already seen.
+func (f *Finder) SetKnownRobotWait(wait time.Duration) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.knownRobotWait = wait
+}
 
-	udpAddr, err := net.ResolveUDPAddr("udp4", f.ip.String()+listenerRemotePort)
+// SetExpiration sets for how long a robot is reported by Robots() after its
+// last broadcast was received.
+func (f *Finder) SetExpiration(expiration time.Duration) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.expiration = expiration
+}
+
+// SetSerial associates the given serial number with the robot with the given
+// MAC address. Serial numbers are not part of the robot broadcast, so they
+// must be provided by whoever learns them (usually after connecting to the
+// robot).
+func (f *Finder) SetSerial(mac net.HardwareAddr, serial string) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.serialsByMAC[mac.String()] = serial
+
+	if robot, ok := f.robotsByMAC[mac.String()]; ok {
+		robot.Serial = serial
+		f.robotsByMAC[mac.String()] = robot
+	}
+}
+
+// Robots returns a snapshot of all robots seen in the network that did not
+// expire yet, sorted by IP.
+func (f *Finder) Robots() []Robot {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.expireRobotsLocked(time.Now())
+
+	robots := make([]Robot, 0, len(f.robotsByMAC))
+	for _, robot := range f.robotsByMAC {
+		robots = append(robots, robot)
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		return bytes.Compare(robots[i].IP, robots[j].IP) < 0
+	})
+
+	return robots
+}
+
+// RobotByMAC returns the non-expired robot with the given MAC address. Returns
+// the robot and true if found and an empty Robot and false otherwise.
+func (f *Finder) RobotByMAC(mac net.HardwareAddr) (Robot, bool) {
+	return f.findRobot(func(robot Robot) bool {
+		return bytes.Equal(robot.MAC, mac)
+	})
+}
+
+// RobotBySerial returns the non-expired robot with the given serial number.
+// Returns the robot and true if found and an empty Robot and false otherwise.
+func (f *Finder) RobotBySerial(serial string) (Robot, bool) {
+	return f.findRobot(func(robot Robot) bool {
+		return robot.Serial == serial
+	})
+}
+
+// Discover starts listening for robot broadcasts and returns a channel where
+// every valid broadcast received is reported as a Robot. Malformed broadcasts
+// are skipped. Discovery stops and the channel is closed when the given
+// context is done. Only one discovery runs at a time, so this waits for any
+// other discovery to stop first. Returns the channel and a nil error on
+// success and a nil channel and a non-nil error on failure (including the
+// context being done while waiting).
+func (f *Finder) Discover(ctx context.Context) (<-chan Robot, error) {
+	select {
+	case f.discoverySem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("error waiting for discovery: %w", ctx.Err())
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	packetConn, err := f.listenConfigLocked().ListenPacket(ctx, "udp4",
+		fmt.Sprintf(":%d", f.broadcastPort))
 	if err != nil {
-		f.l.ERROR("Error resolving UDP address: %s", err.Error())
-		return
+		<-f.discoverySem
+		return nil, fmt.Errorf("error starting packet listener: %w", err)
 	}
 
-	conn, err := net.DialUDP("udp4", nil, udpAddr)
+	// ACKs are sent from the discovery socket while it is open.
+	f.discoveryConn = packetConn
+
+	robotChan := make(chan Robot)
+
+	go func() {
+		<-ctx.Done()
+		packetConn.Close()
+	}()
+
+	go f.discoverLoop(ctx, packetConn, robotChan)
+
+	return robotChan, nil
+}
+
+// WaitForRobot waits until a robot for which match returns true is detected
+// in the network. A nil match matches any robot. Returns the robot and a nil
+// error on success and an empty Robot and a non-nil error on failure
+// (including the context being done before a robot is found).
+func (f *Finder) WaitForRobot(ctx context.Context,
+	match func(Robot) bool) (Robot, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	robotChan, err := f.Discover(ctx)
 	if err != nil {
-		f.l.ERROR("Error dialing UDP: %s", err.Error())
-		return
+		cancel()
+		return Robot{}, err
+	}
+	defer stopDiscovery(cancel, robotChan)
+
+	for robot := range robotChan {
+		if match == nil || match(robot) {
+			return robot, nil
+		}
 	}
-	defer conn.Close()
 
-	_, err = conn.Write(buffer)
+	if ctx.Err() != nil {
+		return Robot{}, ctx.Err()
+	}
+
+	return Robot{}, fmt.Errorf("discovery stopped unexpectedly")
+}
+
+// SendACK sends our app ID to the associated robot, acknowledging its
+// broadcast.
+func (f *Finder) SendACK() {
+	f.m.Lock()
+	ip := f.ip
+	f.m.Unlock()
+
+	err := f.sendACK(ip, f.AppID())
 	if err != nil {
-		f.l.ERROR("Error writing to UDP: %s", err.Error())
-		return
+		f.l.ERROR("Error sending ACK: %s", err.Error())
 	}
 }
 
-func findRobotIP(timeout time.Duration) (net.IP, uint64, error) {
-	packetConn, err := net.ListenPacket("udp4", ipBroadcastAddrPort)
+func (f *Finder) sendACK(ip net.IP, appID uint64) error {
+	f.m.Lock()
+	ackPort := f.ackPort
+	conn := f.discoveryConn
+	if conn == nil {
+		// Use a new socket bound the same way as the discovery one.
+		localAddr := ":0"
+		if f.localIP != nil {
+			localAddr = net.JoinHostPort(f.localIP.String(), "0")
+		}
+
+		var err error
+		conn, err = f.listenConfigLocked().ListenPacket(
+			context.Background(), "udp4", localAddr)
+		if err != nil {
+			f.m.Unlock()
+			return fmt.Errorf("error creating UDP socket: %w", err)
+		}
+		defer conn.Close()
+	}
+	f.m.Unlock()
+
+	f.l.TRACE("Sending ACK to %s:%d.", ip.String(), ackPort)
+
+	buffer := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buffer, appID)
+
+	_, err := conn.WriteTo(buffer, &net.UDPAddr{IP: ip, Port: ackPort})
 	if err != nil {
-		return nil, 0, fmt.Errorf("error starting packet listner: %w", err)
+		return fmt.Errorf("error writing to UDP: %w", err)
 	}
-	defer packetConn.Close()
+
+	return nil
+}
+
+// stopDiscovery cancels a discovery started with Discover and waits for it to
+// stop, so the broadcast port is available again when it returns.
+func stopDiscovery(cancel context.CancelFunc, robotChan <-chan Robot) {
+	cancel()
+
+	for range robotChan {
+	}
+}
+
+func (f *Finder) discoverLoop(ctx context.Context, packetConn net.PacketConn,
+	robotChan chan<- Robot) {
+	// The channel is only closed after the listener is closed and the next
+	// discovery is allowed, so stopDiscovery returning means a new discovery
+	// can start right away.
+	defer close(robotChan)
+	defer func() { <-f.discoverySem }()
+	defer func() {
+		f.m.Lock()
+		f.discoveryConn = nil
+		f.m.Unlock()
+
+		packetConn.Close()
+	}()
 
 	buf := make([]byte, 1024)
 
-	err = packetConn.SetReadDeadline(time.Now().Add(timeout))
-	if err != nil {
-		return nil, 0, fmt.Errorf("error setting deadline: %w", err)
+	for {
+		n, addr, err := packetConn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				f.l.ERROR("Error reading packet: %s", err.Error())
+			}
+
+			return
+		}
+
+		robot, err := f.handlePacket(buf[:n], addr, time.Now())
+		if err != nil {
+			f.l.TRACE("Skipping broadcast from %s: %s", addr, err.Error())
+			continue
+		}
+
+		select {
+		case robotChan <- robot:
+		case <-ctx.Done():
+			return
+		}
 	}
+}
 
-	n, addr, err := packetConn.ReadFrom(buf)
+// handlePacket parses and validates the given broadcast packet and updates the
+// known robots with its information. Returns the updated robot and a nil error
+// on success and an empty Robot and a non-nil error on failure.
+func (f *Finder) handlePacket(buf []byte, addr net.Addr,
+	now time.Time) (Robot, error) {
+	broadcastMessage, err := parseAndValidateMessage(buf, addr)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error reading packet: %w", err)
+		return Robot{}, fmt.Errorf("error validating message: %w", err)
 	}
 
-	ip, remoteAppID, err := parseAndValidateMessage(buf[:n], addr)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error validating message: %w", err)
+	robot := newRobotFromBroadcastMessage(broadcastMessage, now)
+
+	if !f.isReachable(robot.IP) {
+		return Robot{}, fmt.Errorf("robot %s is not reachable through the "+
+			"selected interface", robot.IP)
+	}
+
+	f.m.Lock()
+	robot.Serial = f.serialsByMAC[robot.MAC.String()]
+	f.robotsByMAC[robot.MAC.String()] = robot
+	registry := f.registry
+	f.m.Unlock()
+
+	// Keep the last known ip of known robots up to date. Robots broadcast
+	// constantly, so only write to the registry when the ip actually changed.
+	if registry != nil {
+		known, ok := registry.Lookup(robot.MAC.String())
+		if ok && !known.LastIP.Equal(robot.IP) {
+			err = registry.Update(KnownRobot{MAC: robot.MAC.String(),
+				LastIP: robot.IP})
+			if err == nil {
+				err = registry.Save()
+			}
+			if err != nil {
+				f.l.WARNING("Error updating registry: %s", err.Error())
+			}
+		}
 	}
 
-	return ip, remoteAppID, nil
+	return robot, nil
 }
 
-func parseAndValidateMessage(buf []byte, addr net.Addr) (net.IP, uint64, error) {
+// findKnownRobot looks for a bindable robot that is in the registry, so
+// robots we connected to before are preferred over others. A known robot
+// already seen at its last known ip is returned right away. Otherwise, it
+// waits a short time (see SetKnownRobotWait) for one to show up. Returns the
+// robot and a nil error on success and an empty Robot and a non-nil error on
+// failure.
+func (f *Finder) findKnownRobot(ctx context.Context) (Robot, error) {
+	r := f.Registry()
+	if r == nil || len(r.Robots()) == 0 {
+		return Robot{}, fmt.Errorf("no known robots")
+	}
+
+	isKnown := func(robot Robot) bool {
+		_, ok := r.Lookup(robot.MAC.String())
+		return ok && f.IsBindable(robot)
+	}
+
+	robot, ok := f.findRobot(func(robot Robot) bool {
+		known, ok := r.Lookup(robot.MAC.String())
+		return ok && known.LastIP.Equal(robot.IP) && f.IsBindable(robot)
+	})
+	if ok {
+		return robot, nil
+	}
+
+	f.m.Lock()
+	wait := f.knownRobotWait
+	f.m.Unlock()
+
+	if wait <= 0 {
+		return Robot{}, fmt.Errorf("no known robots seen")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	return f.WaitForRobot(ctx, isKnown)
+}
+
+func (f *Finder) findRobot(match func(Robot) bool) (Robot, bool) {
+	for _, robot := range f.Robots() {
+		if match(robot) {
+			return robot, true
+		}
+	}
+
+	return Robot{}, false
+}
+
+func (f *Finder) expireRobotsLocked(now time.Time) {
+	for mac, robot := range f.robotsByMAC {
+		if now.Sub(robot.LastSeen) > f.expiration {
+			delete(f.robotsByMAC, mac)
+		}
+	}
+}
+
+func parseAndValidateMessage(buf []byte, addr net.Addr) (*BroadcastMessage, error) {
 	broadcastMessage, err := ParseBroadcastMessageData(buf)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error parsing broadcast message: %w", err)
+		return nil, fmt.Errorf("error parsing broadcast message: %w", err)
 	}
 
-	// TODO(bga): Enable this again when we implement pairing. Right now assume
-	// we want to pair with any robot that is broadcasting its ip.
-	//if !broadcastMessage.IsPairing() {
-	//	return nil, 0, nil
-	//}
-
 	// Get IP and make sure it is IPv4
 	ip := net.IP(broadcastMessage.SourceIp()).To4()
 	if ip == nil {
-		return nil, 0, fmt.Errorf("not an IPv4 address")
+		return nil, fmt.Errorf("not an IPv4 address")
 	}
 
-	if !ip.Equal(addr.(*net.UDPAddr).IP) {
-		return nil, 0, fmt.Errorf("broadcast message source does not match reported IP")
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok || !ip.Equal(udpAddr.IP) {
+		return nil, fmt.Errorf("broadcast message source does not match reported IP")
 	}
 
-	return ip, broadcastMessage.AppId(), nil
+	return broadcastMessage, nil
 }
@@ -0,0 +1,140 @@
+package finder
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/support"
+)
+
+func newTestFinder() *Finder {
+	return New(support.NewLogger(nil, nil, nil, nil))
+}
+
+func newTestBroadcastData(ip net.IP, mac net.HardwareAddr, appID uint64,
+	isPairing bool) []byte {
+	data, err := NewBroadcastMessage(isPairing, ip, mac, appID).MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func TestFinder_HandlePacket_Success(t *testing.T) {
+	f := newTestFinder()
+
+	ip := net.IPv4(192, 168, 2, 1).To4()
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	robot, err := f.handlePacket(newTestBroadcastData(ip, mac, 1234, true),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if !robot.IP.Equal(ip) || robot.MAC.String() != mac.String() ||
+		robot.AppID != 1234 || !robot.IsPairing {
+		t.Fatalf("unexpected robot: %s", robot)
+	}
+
+	robots := f.Robots()
+	if len(robots) != 1 {
+		t.Fatalf("expected 1 robot, got %d", len(robots))
+	}
+}
+
+func TestFinder_HandlePacket_Errors(t *testing.T) {
+	f := newTestFinder()
+
+	ip := net.IPv4(192, 168, 2, 1).To4()
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	_, err := f.handlePacket([]byte{1, 2, 3}, &net.UDPAddr{IP: ip},
+		time.Now()) // Invalid length.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	data := newTestBroadcastData(ip, mac, 1234, false)
+	data[0] ^= 0xff
+	_, err = f.handlePacket(data, &net.UDPAddr{IP: ip},
+		time.Now()) // Invalid header.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	_, err = f.handlePacket(newTestBroadcastData(ip, mac, 1234, false),
+		&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1)},
+		time.Now()) // Source mismatch.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	if len(f.Robots()) != 0 {
+		t.Fatalf("expected no robots, got %d", len(f.Robots()))
+	}
+}
+
+func TestFinder_Robots_Expiration(t *testing.T) {
+	f := newTestFinder()
+	f.SetExpiration(time.Minute)
+
+	ip1 := net.IPv4(192, 168, 2, 1).To4()
+	mac1 := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+	ip2 := net.IPv4(192, 168, 2, 2).To4()
+	mac2 := net.HardwareAddr{1, 2, 3, 4, 5, 7}
+
+	_, err := f.handlePacket(newTestBroadcastData(ip1, mac1, 1, false),
+		&net.UDPAddr{IP: ip1}, time.Now().Add(-2*time.Minute))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	_, err = f.handlePacket(newTestBroadcastData(ip2, mac2, 2, false),
+		&net.UDPAddr{IP: ip2}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	robots := f.Robots()
+	if len(robots) != 1 {
+		t.Fatalf("expected 1 robot, got %d", len(robots))
+	}
+	if robots[0].MAC.String() != mac2.String() {
+		t.Fatalf("expected robot %s, got %s", mac2, robots[0].MAC)
+	}
+}
+
+func TestFinder_RobotByMACAndSerial(t *testing.T) {
+	f := newTestFinder()
+
+	ip := net.IPv4(192, 168, 2, 1).To4()
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	f.SetSerial(mac, "3JKDH2T001ABCD")
+
+	_, err := f.handlePacket(newTestBroadcastData(ip, mac, 1, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	robot, ok := f.RobotByMAC(mac)
+	if !ok {
+		t.Fatalf("expected robot with MAC %s to be found", mac)
+	}
+	if robot.Serial != "3JKDH2T001ABCD" {
+		t.Fatalf("expected serial to be set, got %q", robot.Serial)
+	}
+
+	_, ok = f.RobotBySerial("3JKDH2T001ABCD")
+	if !ok {
+		t.Fatalf("expected robot with serial to be found")
+	}
+
+	_, ok = f.RobotBySerial("unknown")
+	if ok {
+		t.Fatalf("expected robot with unknown serial not to be found")
+	}
+}
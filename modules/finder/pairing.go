@@ -0,0 +1,237 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// How often we send ACKs to a robot while waiting for it to confirm the
+	// pairing.
+	pairingACKInterval = 500 * time.Millisecond
+)
+
+// ErrNotPairing is returned when trying to pair with a robot that is not in
+// pairing mode and that was not paired with us before.
+var ErrNotPairing = errors.New("robot is not in pairing mode")
+
+// AppID returns the app ID used by this Finder to pair with robots. If no app
+// ID was explicitly set, it is loaded from (or created at) the default app ID
+// path so it is stable across runs.
+func (f *Finder) AppID() uint64 {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.appID == 0 {
+		f.appID = f.loadAppIDLocked()
+	}
+
+	return f.appID
+}
+
+// SetAppID forces the app ID used by this Finder to pair with robots to be the
+// given one.
+func (f *Finder) SetAppID(appID uint64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.appID = appID
+}
+
+// IsBindable returns true if we can bind to the given robot. This is the case
+// if the robot is broadcasting in pairing mode or if it is broadcasting our
+// own app ID (i.e. it was paired with us before) and it was not explicitly
+// unpaired.
+func (f *Finder) IsBindable(robot Robot) bool {
+	if robot.IsPairing {
+		return true
+	}
+
+	appID := f.AppID()
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return robot.AppID == appID && !f.unpairedByMAC[robot.MAC.String()]
+}
+
+// Pair pairs with the given robot and makes it the robot associated with this
+// Finder. If the robot is in pairing mode, our app ID is sent to it until it
+// confirms the pairing by broadcasting our app ID back. A robot that is already
+// broadcasting our app ID is considered paired. Returns a nil error on success
+// and a non-nil error on failure (including the context being done before the
+// robot confirms the pairing).
+func (f *Finder) Pair(ctx context.Context, robot Robot) error {
+	appID := f.AppID()
+
+	if robot.AppID == appID {
+		f.setPaired(robot)
+		return nil
+	}
+
+	if !robot.IsPairing {
+		return fmt.Errorf("error pairing with robot %s: %w", robot.MAC,
+			ErrNotPairing)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	robotChan, err := f.Discover(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("error pairing with robot %s: %w", robot.MAC, err)
+	}
+	defer stopDiscovery(cancel, robotChan)
+
+	sendACK := func() {
+		err := f.sendACK(robot.IP, appID)
+		if err != nil {
+			f.l.WARNING("Error sending pairing ACK: %s", err.Error())
+		}
+	}
+
+	ticker := time.NewTicker(pairingACKInterval)
+	defer ticker.Stop()
+
+	// ACKs are sent once per tick, no matter how many broadcasts arrive.
+	sendACK()
+
+	for {
+		select {
+		case <-ticker.C:
+			sendACK()
+		case r, ok := <-robotChan:
+			if !ok {
+				return fmt.Errorf("error pairing with robot %s: discovery "+
+					"stopped: %w", robot.MAC, ctx.Err())
+			}
+
+			if bytes.Equal(r.MAC, robot.MAC) && r.AppID == appID {
+				f.l.INFO("Paired with robot %s.", r.MAC)
+				f.setPaired(r)
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("error pairing with robot %s: %w", robot.MAC,
+				ctx.Err())
+		}
+	}
+}
+
+// SetUnpairedPath sets the file where explicitly unpaired robots are
+// persisted and loads any robots already unpaired in it. By default, they are
+// persisted next to the app ID when it is loaded from the default app ID path
+// (see AppID). Returns a nil error on success and a non-nil error on failure.
+func (f *Finder) SetUnpairedPath(path string) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.unpairedPath = path
+
+	return f.loadUnpairedLocked()
+}
+
+// Unpair forgets the pairing with the given robot. It will not be considered
+// bindable again until it is back in pairing mode, even across restarts (see
+// SetUnpairedPath). If the robot is the one associated with this Finder, the
+// association is cleared.
+func (f *Finder) Unpair(robot Robot) {
+	// Make sure persisted unpaired robots are loaded before saving them.
+	f.AppID()
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.unpairedByMAC[robot.MAC.String()] = true
+	f.saveUnpairedLocked()
+
+	if f.ip.Equal(robot.IP) {
+		f.ip = nil
+		f.mac = nil
+	}
+}
+
+func (f *Finder) setPaired(robot Robot) {
+	f.m.Lock()
+	if f.unpairedByMAC[robot.MAC.String()] {
+		delete(f.unpairedByMAC, robot.MAC.String())
+		f.saveUnpairedLocked()
+	}
+	f.m.Unlock()
+
+	f.Select(robot)
+}
+
+func (f *Finder) loadUnpairedLocked() error {
+	macs, err := LoadUnpaired(f.unpairedPath)
+	if err != nil {
+		return err
+	}
+
+	for _, mac := range macs {
+		f.unpairedByMAC[mac.String()] = true
+	}
+
+	return nil
+}
+
+func (f *Finder) saveUnpairedLocked() {
+	if f.unpairedPath == "" {
+		return
+	}
+
+	macs := make([]net.HardwareAddr, 0, len(f.unpairedByMAC))
+	for mac := range f.unpairedByMAC {
+		hardwareAddr, err := net.ParseMAC(mac)
+		if err == nil {
+			macs = append(macs, hardwareAddr)
+		}
+	}
+
+	err := SaveUnpaired(f.unpairedPath, macs)
+	if err != nil {
+		f.l.WARNING("Could not persist unpaired robots: %s", err.Error())
+	}
+}
+
+func (f *Finder) loadAppIDLocked() uint64 {
+	path, err := DefaultAppIDPath()
+	if err == nil {
+		var appID uint64
+		appID, err = LoadOrCreateAppID(path)
+		if err == nil {
+			f.useDefaultUnpairedPathLocked(path)
+			return appID
+		}
+	}
+
+	f.l.WARNING("Could not load persistent app ID, using a temporary one: %s",
+		err.Error())
+
+	appID, err := newAppID()
+	if err != nil {
+		f.l.ERROR("Could not create temporary app ID: %s", err.Error())
+	}
+
+	return appID
+}
+
+// useDefaultUnpairedPathLocked persists unpaired robots next to the app ID at
+// the given path, unless a path was explicitly set.
+func (f *Finder) useDefaultUnpairedPathLocked(appIDPath string) {
+	if f.unpairedPath != "" {
+		return
+	}
+
+	f.unpairedPath = filepath.Join(filepath.Dir(appIDPath), unpairedFileName)
+
+	err := f.loadUnpairedLocked()
+	if err != nil {
+		f.l.WARNING("Could not load unpaired robots: %s", err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package finder
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestFinder_Unpair(t *testing.T) {
+	f := newTestFinder()
+	f.SetAppID(5678)
+
+	robot := Robot{
+		IP:    net.IPv4(127, 0, 0, 1),
+		MAC:   net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		AppID: 5678,
+	}
+
+	if !f.IsBindable(robot) {
+		t.Fatalf("expected robot to be bindable")
+	}
+
+	err := f.Pair(context.Background(), robot)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	f.Unpair(robot)
+
+	if f.IsBindable(robot) {
+		t.Fatalf("expected robot not to be bindable")
+	}
+
+	robot.IsPairing = true
+	if !f.IsBindable(robot) {
+		t.Fatalf("expected robot in pairing mode to be bindable")
+	}
+}
+
+func TestFinder_Unpair_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), unpairedFileName)
+
+	robot := Robot{
+		IP:    net.IPv4(127, 0, 0, 1),
+		MAC:   net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		AppID: 5678,
+	}
+
+	newFinder := func() *Finder {
+		f := newTestFinder()
+		f.SetAppID(5678)
+
+		err := f.SetUnpairedPath(path)
+		if err != nil {
+			t.Fatalf("expected nil error, got %q", err)
+		}
+
+		return f
+	}
+
+	newFinder().Unpair(robot)
+
+	// A new Finder (i.e. after a restart) still considers it unpaired.
+	f := newFinder()
+	if f.IsBindable(robot) {
+		t.Fatalf("expected robot not to be bindable")
+	}
+
+	// Pairing again (here with a robot already using our app ID) forgets
+	// that it was unpaired.
+	err := f.Pair(context.Background(), robot)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if !newFinder().IsBindable(robot) {
+		t.Fatalf("expected paired robot to be bindable")
+	}
+}
@@ -0,0 +1,377 @@
+package finder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	registryFileName = "robots.json"
+)
+
+// KnownRobot holds information about a robot we connected to before.
+type KnownRobot struct {
+	MAC              string            `json:"mac"`
+	LastIP           net.IP            `json:"last_ip,omitempty"`
+	AppID            uint64            `json:"app_id,omitempty"`
+	Serial           string            `json:"serial,omitempty"`
+	ProductType      int64             `json:"product_type,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	FirmwareVersions map[string]string `json:"firmware_versions,omitempty"`
+	LastConnected    time.Time         `json:"last_connected,omitempty"`
+}
+
+// Registry is a persistent registry of known robots, indexed by MAC address.
+type Registry struct {
+	path string
+
+	m           sync.Mutex
+	robotsByMAC map[string]KnownRobot
+}
+
+// DefaultRegistryPath returns the path of the file where known robots are
+// persisted by default. Returns the path and a nil error on success and an
+// empty string and a non-nil error on failure.
+func DefaultRegistryPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, registryFileName), nil
+}
+
+// LoadRegistry loads the registry persisted at the given path. A missing file
+// results in an empty registry. Returns the Registry instance and a nil error
+// on success and nil and a non-nil error on failure.
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path,
+		sync.Mutex{},
+		make(map[string]KnownRobot),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return r, nil
+		}
+
+		return nil, fmt.Errorf("error reading registry: %w", err)
+	}
+
+	var robots []KnownRobot
+	err = json.Unmarshal(data, &robots)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing registry: %w", err)
+	}
+
+	for _, robot := range robots {
+		r.robotsByMAC[normalizeMAC(robot.MAC)] = robot
+	}
+
+	return r, nil
+}
+
+// Save persists the registry to the path it was loaded from. Returns a nil
+// error on success and a non-nil error on failure.
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r.Robots(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding registry: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.path), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating registry directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failure never leaves a truncated
+	// registry behind.
+	tmpPath := r.path + ".tmp"
+
+	err = os.WriteFile(tmpPath, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing registry: %w", err)
+	}
+
+	err = os.Rename(tmpPath, r.path)
+	if err != nil {
+		return fmt.Errorf("error writing registry: %w", err)
+	}
+
+	return nil
+}
+
+// Robots returns all known robots, sorted by name and then by MAC address.
+func (r *Registry) Robots() []KnownRobot {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	robots := make([]KnownRobot, 0, len(r.robotsByMAC))
+	for _, robot := range r.robotsByMAC {
+		robots = append(robots, robot)
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		if robots[i].Name != robots[j].Name {
+			return robots[i].Name < robots[j].Name
+		}
+
+		return robots[i].MAC < robots[j].MAC
+	})
+
+	return robots
+}
+
+// Lookup returns the known robot with the given name, serial number or MAC
+// address, in that order. Returns the robot and true if found and an empty
+// KnownRobot and false otherwise.
+func (r *Registry) Lookup(nameSerialOrMAC string) (KnownRobot, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, robot := range r.robotsByMAC {
+		if robot.Name != "" && robot.Name == nameSerialOrMAC {
+			return robot, true
+		}
+	}
+
+	for _, robot := range r.robotsByMAC {
+		if robot.Serial != "" && robot.Serial == nameSerialOrMAC {
+			return robot, true
+		}
+	}
+
+	robot, ok := r.robotsByMAC[normalizeMAC(nameSerialOrMAC)]
+
+	return robot, ok
+}
+
+// LookupByIP returns the known robot that was last seen with the given ip.
+// Returns the robot and true if found and an empty KnownRobot and false
+// otherwise.
+func (r *Registry) LookupByIP(ip net.IP) (KnownRobot, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, robot := range r.robotsByMAC {
+		if robot.LastIP.Equal(ip) {
+			return robot, true
+		}
+	}
+
+	return KnownRobot{}, false
+}
+
+// Update merges the given robot information into the registry. Empty fields
+// in the given robot do not overwrite existing information. The registry is not
+// persisted until Save is called. Returns a nil error on success and a non-nil
+// error on failure.
+func (r *Registry) Update(robot KnownRobot) error {
+	mac := normalizeMAC(robot.MAC)
+	if mac == "" {
+		return fmt.Errorf("invalid MAC address %q", robot.MAC)
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	known := r.robotsByMAC[mac]
+	known.MAC = mac
+
+	if robot.LastIP != nil {
+		known.LastIP = robot.LastIP
+	}
+	if robot.AppID != 0 {
+		known.AppID = robot.AppID
+	}
+	if robot.Serial != "" {
+		known.Serial = robot.Serial
+	}
+	if robot.ProductType != 0 {
+		known.ProductType = robot.ProductType
+	}
+	if robot.Name != "" {
+		known.Name = robot.Name
+	}
+	if !robot.LastConnected.IsZero() {
+		known.LastConnected = robot.LastConnected
+	}
+
+	for component, version := range robot.FirmwareVersions {
+		if known.FirmwareVersions == nil {
+			known.FirmwareVersions = make(map[string]string)
+		}
+		known.FirmwareVersions[component] = version
+	}
+
+	r.robotsByMAC[mac] = known
+
+	return nil
+}
+
+// SetName sets the friendly name of the known robot with the given MAC
+// address. Names must be unique. Returns a nil error on success and a non-nil
+// error on failure.
+func (r *Registry) SetName(mac string, name string) error {
+	mac = normalizeMAC(mac)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	robot, ok := r.robotsByMAC[mac]
+	if !ok {
+		return fmt.Errorf("unknown robot %q", mac)
+	}
+
+	for otherMAC, other := range r.robotsByMAC {
+		if otherMAC != mac && name != "" && other.Name == name {
+			return fmt.Errorf("name %q already used by robot %s", name,
+				otherMAC)
+		}
+	}
+
+	robot.Name = name
+	r.robotsByMAC[mac] = robot
+
+	return nil
+}
+
+// Remove removes the known robot with the given MAC address.
+func (r *Registry) Remove(mac string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	delete(r.robotsByMAC, normalizeMAC(mac))
+}
+
+// SetRegistry sets the registry of known robots used by this Finder. Known
+// serial numbers are associated with discovered robots and SelectKnown can be
+// used to skip discovery for known robots.
+func (f *Finder) SetRegistry(r *Registry) {
+	f.m.Lock()
+	f.registry = r
+	f.m.Unlock()
+
+	if r == nil {
+		return
+	}
+
+	for _, robot := range r.Robots() {
+		mac, err := net.ParseMAC(robot.MAC)
+		if err != nil || robot.Serial == "" {
+			continue
+		}
+
+		f.SetSerial(mac, robot.Serial)
+	}
+}
+
+// Registry returns the registry of known robots used by this Finder (which
+// might be nil).
+func (f *Finder) Registry() *Registry {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.registry
+}
+
+// SelectKnown makes the known robot with the given name, serial number or MAC
+// address the one associated with this Finder, using its last known ip. This
+// allows reconnecting without waiting for the robot broadcast. Returns a nil
+// error on success and a non-nil error on failure.
+func (f *Finder) SelectKnown(nameSerialOrMAC string) error {
+	r := f.Registry()
+	if r == nil {
+		return fmt.Errorf("no registry of known robots")
+	}
+
+	known, ok := r.Lookup(nameSerialOrMAC)
+	if !ok {
+		return fmt.Errorf("unknown robot %q", nameSerialOrMAC)
+	}
+
+	if known.LastIP == nil {
+		return fmt.Errorf("no known ip for robot %q", nameSerialOrMAC)
+	}
+
+	mac, err := net.ParseMAC(known.MAC)
+	if err != nil {
+		return fmt.Errorf("invalid MAC address for robot %q: %w",
+			nameSerialOrMAC, err)
+	}
+
+	f.Select(Robot{
+		IP:     known.LastIP,
+		MAC:    mac,
+		AppID:  known.AppID,
+		Serial: known.Serial,
+	})
+
+	return nil
+}
+
+// UpdateRegistry records the robot associated with this Finder as connected
+// now in the registry, merging the given extra information into it, and
+// persists the registry. The robot is identified by its MAC address or, if it
+// was selected by ip only, by its last known ip. Returns a nil error on success
+// and a non-nil error on failure.
+func (f *Finder) UpdateRegistry(info KnownRobot) error {
+	r := f.Registry()
+	if r == nil {
+		return fmt.Errorf("no registry of known robots")
+	}
+
+	f.m.Lock()
+	ip := f.ip
+	mac := f.mac
+	f.m.Unlock()
+
+	if ip == nil {
+		return fmt.Errorf("no associated robot")
+	}
+
+	info.MAC = mac.String()
+	if mac == nil {
+		known, ok := r.LookupByIP(ip)
+		if !ok {
+			return fmt.Errorf("unknown MAC address for robot %s", ip)
+		}
+
+		info.MAC = known.MAC
+	}
+
+	info.LastIP = ip
+	info.AppID = f.AppID()
+	info.LastConnected = time.Now()
+
+	err := r.Update(info)
+	if err != nil {
+		return err
+	}
+
+	if info.Serial != "" && mac != nil {
+		f.SetSerial(mac, info.Serial)
+	}
+
+	return r.Save()
+}
+
+func normalizeMAC(mac string) string {
+	hardwareAddr, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return ""
+	}
+
+	return hardwareAddr.String()
+}
@@ -0,0 +1,250 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistry_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), registryFileName)
+
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if len(r.Robots()) != 0 {
+		t.Fatalf("expected empty registry, got %d robots", len(r.Robots()))
+	}
+
+	err = r.Update(KnownRobot{
+		MAC:              "01:02:03:04:05:06",
+		LastIP:           net.IPv4(192, 168, 2, 1),
+		Serial:           "3JKDH2T001ABCD",
+		FirmwareVersions: map[string]string{"system": "01.01.0500"},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	err = r.SetName("01-02-03-04-05-06", "Blue")
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	err = r.Save()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	r, err = LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	for _, key := range []string{"Blue", "3JKDH2T001ABCD", "01:02:03:04:05:06"} {
+		known, ok := r.Lookup(key)
+		if !ok {
+			t.Fatalf("expected robot %q to be found", key)
+		}
+		if !known.LastIP.Equal(net.IPv4(192, 168, 2, 1)) ||
+			known.FirmwareVersions["system"] != "01.01.0500" {
+			t.Fatalf("unexpected known robot: %+v", known)
+		}
+	}
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join(t.TempDir(), registryFileName))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	err = r.Update(KnownRobot{MAC: "invalid"})
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	err = r.SetName("01:02:03:04:05:06", "Blue") // Unknown robot.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	r.Update(KnownRobot{MAC: "01:02:03:04:05:06", Name: "Blue"})
+	r.Update(KnownRobot{MAC: "01:02:03:04:05:07"})
+
+	err = r.SetName("01:02:03:04:05:07", "Blue") // Duplicated name.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
+
+func TestFinder_SelectKnownAndUpdateRegistry(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join(t.TempDir(), registryFileName))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	r.Update(KnownRobot{
+		MAC:    "01:02:03:04:05:06",
+		LastIP: net.IPv4(192, 168, 2, 1),
+		Serial: "3JKDH2T001ABCD",
+		Name:   "Blue",
+	})
+
+	f := newTestFinder()
+	f.SetAppID(5678)
+	f.SetRegistry(r)
+
+	err = f.SelectKnown("Unknown")
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	err = f.SelectKnown("Blue")
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	ip, err := f.GetOrFindIP(time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if !ip.Equal(net.IPv4(192, 168, 2, 1)) {
+		t.Fatalf("expected 192.168.2.1, got %s", ip)
+	}
+
+	err = f.UpdateRegistry(KnownRobot{ProductType: 1})
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	known, _ := r.Lookup("Blue")
+	if known.AppID != 5678 || known.ProductType != 1 ||
+		known.LastConnected.IsZero() {
+		t.Fatalf("unexpected known robot: %+v", known)
+	}
+
+	// Serial numbers from the registry are associated with discovered robots.
+	ip = net.IPv4(192, 168, 2, 3).To4()
+	robot, err := f.handlePacket(newTestBroadcastData(ip,
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 5678, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if robot.Serial != "3JKDH2T001ABCD" {
+		t.Fatalf("expected serial to be set, got %q", robot.Serial)
+	}
+
+	known, _ = r.Lookup("Blue")
+	if !known.LastIP.Equal(ip) {
+		t.Fatalf("expected last ip to be updated, got %s", known.LastIP)
+	}
+}
+
+func TestFinder_HandlePacket_SavesRegistryOnlyOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), registryFileName)
+
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	r.Update(KnownRobot{
+		MAC:    "01:02:03:04:05:06",
+		LastIP: net.IPv4(192, 168, 2, 3),
+	})
+
+	f := newTestFinder()
+	f.SetRegistry(r)
+
+	// Same ip. Nothing to save.
+	ip := net.IPv4(192, 168, 2, 3).To4()
+	_, err = f.handlePacket(newTestBroadcastData(ip,
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 5678, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	_, err = os.Stat(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected registry not to be saved, got %v", err)
+	}
+
+	// New ip. Registry is saved.
+	ip = net.IPv4(192, 168, 2, 4).To4()
+	_, err = f.handlePacket(newTestBroadcastData(ip,
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 5678, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	r, err = LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	known, ok := r.Lookup("01:02:03:04:05:06")
+	if !ok || !known.LastIP.Equal(ip) {
+		t.Fatalf("expected saved last ip %s, got %+v", ip, known)
+	}
+}
+
+func TestFinder_FindKnownRobot_AlreadySeen(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join(t.TempDir(), registryFileName))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	ip := net.IPv4(192, 168, 2, 3).To4()
+
+	r.Update(KnownRobot{MAC: "01:02:03:04:05:06", LastIP: ip})
+
+	f := newTestFinder()
+	f.SetAppID(5678)
+	f.SetRegistry(r)
+	f.SetKnownRobotWait(0)
+
+	// Nothing seen yet and no waiting allowed.
+	_, err = f.findKnownRobot(context.Background())
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	// An unknown robot is not considered.
+	_, err = f.handlePacket(newTestBroadcastData(net.IPv4(192, 168, 2, 4),
+		net.HardwareAddr{1, 2, 3, 4, 5, 7}, 5678, false),
+		&net.UDPAddr{IP: net.IPv4(192, 168, 2, 4)}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	_, err = f.findKnownRobot(context.Background())
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	// The known robot at its last ip is returned without any discovery.
+	_, err = f.handlePacket(newTestBroadcastData(ip,
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 5678, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	robot, err := f.findKnownRobot(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if !robot.IP.Equal(ip) {
+		t.Fatalf("expected ip %s, got %s", ip, robot.IP)
+	}
+}
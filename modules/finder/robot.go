@@ -0,0 +1,37 @@
+package finder
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Robot holds information about a robot that was detected broadcasting in the
+// network.
+type Robot struct {
+	IP        net.IP
+	MAC       net.HardwareAddr
+	AppID     uint64
+	IsPairing bool
+	Serial    string
+	LastSeen  time.Time
+}
+
+// newRobotFromBroadcastMessage returns a Robot instance with the information
+// in the given BroadcastMessage and the given last seen time.
+func newRobotFromBroadcastMessage(b *BroadcastMessage,
+	lastSeen time.Time) Robot {
+	return Robot{
+		IP:        b.SourceIp(),
+		MAC:       b.SourceMac(),
+		AppID:     b.AppId(),
+		IsPairing: b.IsPairing(),
+		LastSeen:  lastSeen,
+	}
+}
+
+func (r Robot) String() string {
+	return fmt.Sprintf("IP:%s, MAC:%s, AppID:%d, IsPairing:%t, Serial:%q, "+
+		"LastSeen:%s", r.IP, r.MAC, r.AppID, r.IsPairing, r.Serial,
+		r.LastSeen.Format(time.RFC3339))
+}
@@ -0,0 +1,250 @@
+package finder
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+var (
+	// IP robots use for themselves when acting as an access point.
+	robotDirectModeIP = net.IPv4(192, 168, 2, 1)
+)
+
+// ConnectionMode describes how a robot is connected to the network.
+type ConnectionMode int
+
+const (
+	ConnectionModeUnknown ConnectionMode = iota
+
+	// The robot is acting as an access point we are directly connected to.
+	ConnectionModeDirect
+
+	// The robot is connected to a router, same as us.
+	ConnectionModeRouter
+)
+
+func (c ConnectionMode) String() string {
+	switch c {
+	case ConnectionModeDirect:
+		return "Direct"
+	case ConnectionModeRouter:
+		return "Router"
+	}
+
+	return "Unknown"
+}
+
+// ConnectionModeForIP returns the ConnectionMode of a robot with the given ip.
+func ConnectionModeForIP(ip net.IP) ConnectionMode {
+	if ip.To4() == nil {
+		return ConnectionModeUnknown
+	}
+
+	if ip.Equal(robotDirectModeIP) {
+		return ConnectionModeDirect
+	}
+
+	return ConnectionModeRouter
+}
+
+// Route describes how we reach a robot.
+type Route struct {
+	Interface string
+	LocalIP   net.IP
+	RobotIP   net.IP
+	Mode      ConnectionMode
+}
+
+func (r Route) String() string {
+	return fmt.Sprintf("Interface:%s, LocalIP:%s, RobotIP:%s, Mode:%s",
+		r.Interface, r.LocalIP, r.RobotIP, r.Mode)
+}
+
+// SetInterface binds robot discovery and ACK sending to the network interface
+// with the given name, so broadcasts are only received from it and ACKs are
+// sent through it, from its address. Robots outside the interface networks
+// are ignored. An empty name removes any restriction. Returns a nil error on
+// success and a non-nil error on failure.
+//
+// Binding to an interface is only supported on Linux. On other platforms,
+// broadcasts are received on all interfaces and only filtered by network, and
+// ACKs leave through the interface chosen by the routing table.
+func (f *Finder) SetInterface(name string) error {
+	if name == "" {
+		f.setLocalNets("", nil, nil)
+		return nil
+	}
+
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return fmt.Errorf("error getting interface %q: %w", name, err)
+	}
+
+	localNets, err := interfaceIPv4Nets(iface)
+	if err != nil {
+		return err
+	}
+
+	if len(localNets) == 0 {
+		return fmt.Errorf("interface %q has no IPv4 addresses", name)
+	}
+
+	f.setLocalNets(iface.Name, localNets[0].IP, localNets)
+
+	return nil
+}
+
+// SetLocalAddr binds robot discovery and ACK sending to the network interface
+// with the given local IPv4 address (see SetInterface), restricted to robots
+// in the network of that address. ACKs are sent from it. A nil ip removes any
+// restriction. Returns a nil error on success and a non-nil error on failure.
+func (f *Finder) SetLocalAddr(ip net.IP) error {
+	if ip == nil {
+		f.setLocalNets("", nil, nil)
+		return nil
+	}
+
+	if ip.To4() == nil {
+		return fmt.Errorf("not an IPv4 address")
+	}
+
+	iface, localNet, err := interfaceForIP(ip)
+	if err != nil {
+		return err
+	}
+
+	f.setLocalNets(iface.Name, localNet.IP, []*net.IPNet{localNet})
+
+	return nil
+}
+
+// Route returns how the robot associated with this Finder is reached: the
+// local interface and address used and the robot connection mode. Returns the
+// route and a nil error on success and an empty Route and a non-nil error on
+// failure.
+func (f *Finder) Route() (Route, error) {
+	f.m.Lock()
+	robotIP := f.ip
+	localIP := f.localIP
+	ackPort := f.ackPort
+	f.m.Unlock()
+
+	if robotIP == nil {
+		return Route{}, fmt.Errorf("no associated robot")
+	}
+
+	if localIP == nil {
+		// Let the kernel tell us the address it would use to reach the robot.
+		// No packets are actually sent.
+		conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: robotIP,
+			Port: ackPort})
+		if err != nil {
+			return Route{}, fmt.Errorf("error getting route to robot: %w", err)
+		}
+
+		localIP = conn.LocalAddr().(*net.UDPAddr).IP
+		conn.Close()
+	}
+
+	iface, _, err := interfaceForIP(localIP)
+	if err != nil {
+		return Route{}, err
+	}
+
+	return Route{
+		Interface: iface.Name,
+		LocalIP:   localIP,
+		RobotIP:   robotIP,
+		Mode:      ConnectionModeForIP(robotIP),
+	}, nil
+}
+
+func (f *Finder) setLocalNets(iface string, localIP net.IP,
+	localNets []*net.IPNet) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.iface = iface
+	f.localIP = localIP
+	f.localNets = localNets
+}
+
+// listenConfigLocked returns the configuration for sockets used to talk to
+// robots, binding them to the selected interface (if any).
+func (f *Finder) listenConfigLocked() *net.ListenConfig {
+	iface := f.iface
+	if iface == "" {
+		return &net.ListenConfig{}
+	}
+
+	return &net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			return bindToInterface(c, iface)
+		},
+	}
+}
+
+// isReachable returns true if the given robot ip is in one of the networks we
+// are restricted to (or if there is no restriction).
+func (f *Finder) isReachable(ip net.IP) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if len(f.localNets) == 0 {
+		return true
+	}
+
+	for _, localNet := range f.localNets {
+		if localNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func interfaceForIP(ip net.IP) (*net.Interface, *net.IPNet, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing interfaces: %w", err)
+	}
+
+	for i := range ifaces {
+		localNets, err := interfaceIPv4Nets(&ifaces[i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, localNet := range localNets {
+			if localNet.IP.Equal(ip) {
+				return &ifaces[i], localNet, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no interface with address %s", ip)
+}
+
+func interfaceIPv4Nets(iface *net.Interface) ([]*net.IPNet, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("error getting addresses for interface %q: %w",
+			iface.Name, err)
+	}
+
+	var localNets []*net.IPNet
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+
+		localNets = append(localNets, &net.IPNet{
+			IP:   ipNet.IP.To4(),
+			Mask: ipNet.Mask[len(ipNet.Mask)-net.IPv4len:],
+		})
+	}
+
+	return localNets, nil
+}
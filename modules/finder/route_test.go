@@ -0,0 +1,126 @@
+package finder
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func loopbackInterface(t *testing.T) *net.Interface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 {
+			return &ifaces[i]
+		}
+	}
+
+	t.Skip("no loopback interface")
+
+	return nil
+}
+
+func TestConnectionModeForIP(t *testing.T) {
+	if mode := ConnectionModeForIP(net.IPv4(192, 168, 2, 1)); mode != ConnectionModeDirect {
+		t.Fatalf("expected Direct mode, got %s", mode)
+	}
+	if mode := ConnectionModeForIP(net.IPv4(10, 0, 0, 5)); mode != ConnectionModeRouter {
+		t.Fatalf("expected Router mode, got %s", mode)
+	}
+	if mode := ConnectionModeForIP(nil); mode != ConnectionModeUnknown {
+		t.Fatalf("expected Unknown mode, got %s", mode)
+	}
+}
+
+func TestFinder_SetInterface_FiltersRobots(t *testing.T) {
+	f := newTestFinder()
+
+	err := f.SetInterface(loopbackInterface(t).Name)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	ip := net.IPv4(192, 168, 2, 1).To4()
+	_, err = f.handlePacket(newTestBroadcastData(ip, mac, 1, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	ip = net.IPv4(127, 0, 0, 1).To4()
+	_, err = f.handlePacket(newTestBroadcastData(ip, mac, 1, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	err = f.SetInterface("")
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	ip = net.IPv4(192, 168, 2, 1).To4()
+	_, err = f.handlePacket(newTestBroadcastData(ip, mac, 1, false),
+		&net.UDPAddr{IP: ip}, time.Now())
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+}
+
+func TestFinder_SetLocalAddr_Errors(t *testing.T) {
+	f := newTestFinder()
+
+	err := f.SetLocalAddr(net.ParseIP("::1")) // Not IPv4.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	err = f.SetLocalAddr(net.IPv4(203, 0, 113, 1)) // Not local.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
+
+func TestFinder_Route(t *testing.T) {
+	f := newTestFinder()
+
+	_, err := f.Route() // No associated robot.
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	f.SetIP(net.IPv4(127, 0, 0, 1))
+
+	route, err := f.Route()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if route.Interface != loopbackInterface(t).Name {
+		t.Fatalf("expected loopback interface, got %q", route.Interface)
+	}
+	if !route.LocalIP.IsLoopback() {
+		t.Fatalf("expected loopback local IP, got %s", route.LocalIP)
+	}
+	if route.Mode != ConnectionModeRouter {
+		t.Fatalf("expected Router mode, got %s", route.Mode)
+	}
+
+	err = f.SetLocalAddr(net.IPv4(127, 0, 0, 1))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	route, err = f.Route()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if !route.LocalIP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("expected 127.0.0.1, got %s", route.LocalIP)
+	}
+}
@@ -0,0 +1,64 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+// Attitude is a gimbal attitude reported by the robot. Angles are in degrees.
+// Yaw is relative to the heading the gimbal had when the robot started and
+// ChassisYaw is relative to the chassis heading.
+type Attitude struct {
+	Time       time.Time
+	Pitch      float64
+	Yaw        float64
+	ChassisYaw float64
+}
+
+func (a Attitude) String() string {
+	return fmt.Sprintf("Pitch:%.1f deg, Yaw:%.1f deg, ChassisYaw:%.1f deg",
+		a.Pitch, a.Yaw, a.ChassisYaw)
+}
+
+// Attitude returns the latest gimbal attitude reported by the robot. Returns
+// the attitude and true if there is one and an empty Attitude and false
+// otherwise.
+func (g *Gimbal) Attitude() (Attitude, bool) {
+	return g.attitudeStream.Latest()
+}
+
+// SubscribeAttitude returns a channel where gimbal attitude updates are
+// delivered until the given context is done.
+func (g *Gimbal) SubscribeAttitude(ctx context.Context) <-chan Attitude {
+	return g.attitudeStream.Subscribe(ctx)
+}
+
+func (g *Gimbal) startAttitudeUpdates() {
+	service.DJICommandControllerInstance().StartListeningOnKey(
+		dji.DJIGimbalAttitude, g, g.onAttitude, false)
+}
+
+func (g *Gimbal) stopAttitudeUpdates() {
+	cc := service.DJICommandControllerInstance()
+
+	cc.StopListeningOnKey(dji.DJIGimbalAttitude, g)
+	cc.PerformAction(dji.DJIGimbalCloseAttitudeUpdates, nil)
+}
+
+func (g *Gimbal) onAttitude(result *dji.DJIResult) {
+	var value dji.DJIGimbalAttitudeParamValue
+	if !service.DecodeResult(g.logger, result, &value) {
+		return
+	}
+
+	g.attitudeStream.Publish(Attitude{
+		Time:       time.Now(),
+		Pitch:      value.Pitch,
+		Yaw:        value.Yaw,
+		ChassisYaw: value.YawOpposite,
+	})
+}
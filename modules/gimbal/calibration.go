@@ -0,0 +1,173 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+const (
+	// Maximum time a calibration can take.
+	calibrationTimeout = 2 * time.Minute
+)
+
+// CalibrationStatus is the state of a gimbal calibration.
+type CalibrationStatus int64
+
+const (
+	CalibrationStatusIdle CalibrationStatus = iota
+	CalibrationStatusCalibrating
+	CalibrationStatusSucceeded
+	CalibrationStatusFailed
+)
+
+func (c CalibrationStatus) String() string {
+	switch c {
+	case CalibrationStatusIdle:
+		return "Idle"
+	case CalibrationStatusCalibrating:
+		return "Calibrating"
+	case CalibrationStatusSucceeded:
+		return "Succeeded"
+	case CalibrationStatusFailed:
+		return "Failed"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(c))
+}
+
+// CalibrationUpdate reports the progress of a gimbal calibration. Progress is
+// a percentage.
+type CalibrationUpdate struct {
+	Time     time.Time
+	Status   CalibrationStatus
+	Progress int
+}
+
+// CalibrationError is returned by Calibrate when the robot reports the
+// calibration failed.
+type CalibrationError struct {
+	Status   CalibrationStatus
+	Progress int
+}
+
+func (c *CalibrationError) Error() string {
+	return fmt.Sprintf("gimbal calibration failed at %d%% (status %s)",
+		c.Progress, c.Status)
+}
+
+// SubscribeCalibration returns a channel where calibration updates are
+// delivered until the given context is done.
+func (g *Gimbal) SubscribeCalibration(
+	ctx context.Context) <-chan CalibrationUpdate {
+	return g.calibrationStream.Subscribe(ctx)
+}
+
+// Calibrate calibrates the gimbal and waits for calibration to finish. If auto
+// is true, the automatic calibration is used. Otherwise a manual calibration
+// is started. Progress and status changes are reported to subscribers (see
+// SubscribeCalibration). Returns a nil error on success, a *CalibrationError if
+// the robot reports the calibration failed and a non-nil error on any other
+// failure (including the context being done or 2 minutes passing before
+// calibration finishes).
+func (g *Gimbal) Calibrate(ctx context.Context, auto bool) error {
+	ctx, cancel := context.WithTimeout(ctx, calibrationTimeout)
+	defer cancel()
+
+	g.m.Lock()
+	g.calibration = CalibrationUpdate{
+		Status: CalibrationStatusIdle,
+	}
+	g.m.Unlock()
+
+	// Subscribe before calibrating so no status change is missed.
+	updateChan := g.calibrationStream.Subscribe(ctx)
+
+	key := dji.DJIGimbalCalibration
+	if auto {
+		key = dji.DJIGimbalAutoCalibrate
+	}
+
+	_, err := service.PerformActionSync(ctx,
+		service.DJICommandControllerInstance(), key, nil)
+	if err != nil {
+		return fmt.Errorf("error starting gimbal calibration: %w", err)
+	}
+
+	// Only the latest status is kept, so the robot reporting calibration
+	// started might be missed. Any final status after the calibration was
+	// started is accepted.
+	for {
+		select {
+		case update, ok := <-updateChan:
+			if !ok {
+				return fmt.Errorf("error calibrating gimbal: %w", ctx.Err())
+			}
+
+			switch update.Status {
+			case CalibrationStatusSucceeded:
+				return nil
+			case CalibrationStatusFailed:
+				return &CalibrationError{
+					Status:   update.Status,
+					Progress: update.Progress,
+				}
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("error calibrating gimbal: %w", ctx.Err())
+		}
+	}
+}
+
+func (g *Gimbal) startCalibrationUpdates() {
+	cc := service.DJICommandControllerInstance()
+
+	cc.StartListeningOnKey(dji.DJIGimbalCalibrationStatus, g,
+		g.onCalibrationStatus, false)
+	cc.StartListeningOnKey(dji.DJIGimbalCalibrationProgress, g,
+		g.onCalibrationProgress, false)
+}
+
+func (g *Gimbal) stopCalibrationUpdates() {
+	cc := service.DJICommandControllerInstance()
+
+	cc.StopListeningOnKey(dji.DJIGimbalCalibrationProgress, g)
+	cc.StopListeningOnKey(dji.DJIGimbalCalibrationStatus, g)
+}
+
+func (g *Gimbal) onCalibrationStatus(result *dji.DJIResult) {
+	var value int64
+	if !service.DecodeResult(g.logger, result, &value) {
+		return
+	}
+
+	g.m.Lock()
+	g.calibration.Status = CalibrationStatus(value)
+	update := g.calibration
+	g.m.Unlock()
+
+	g.publishCalibration(update)
+}
+
+func (g *Gimbal) onCalibrationProgress(result *dji.DJIResult) {
+	var value int64
+	if !service.DecodeResult(g.logger, result, &value) {
+		return
+	}
+
+	g.m.Lock()
+	g.calibration.Progress = int(value)
+	update := g.calibration
+	g.m.Unlock()
+
+	g.publishCalibration(update)
+}
+
+func (g *Gimbal) publishCalibration(update CalibrationUpdate) {
+	update.Time = time.Now()
+
+	g.calibrationStream.Publish(update)
+}
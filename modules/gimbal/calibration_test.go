@@ -0,0 +1,100 @@
+package gimbal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+	"github.com/brunoga/robomaster2/support"
+)
+
+func TestGimbal_Calibrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []CalibrationStatus
+		progress int
+		failed   bool
+	}{
+		{"Succeeded", []CalibrationStatus{CalibrationStatusSucceeded}, 100,
+			false},
+		{"Failed", []CalibrationStatus{CalibrationStatusFailed}, 40, true},
+		{"CalibratingThenSucceeded", []CalibrationStatus{
+			CalibrationStatusCalibrating, CalibrationStatusSucceeded}, 100,
+			false},
+		{"CalibratingThenFailed", []CalibrationStatus{
+			CalibrationStatusCalibrating, CalibrationStatusFailed}, 70,
+			true},
+	}
+
+	for _, test := range tests {
+		cc := servicetest.Install(t)
+
+		g := New(support.NewLogger(nil, nil, nil, nil))
+		g.startCalibrationUpdates()
+
+		// Statuses might be published before Calibrate sees the action
+		// succeeding, so there is no chance to see any but the last one.
+		cc.OnAction(dji.DJIGimbalAutoCalibrate, func(dji.DJIParamValue) {
+			cc.Publish(dji.DJIGimbalCalibrationProgress, test.progress)
+			for _, status := range test.statuses {
+				cc.Publish(dji.DJIGimbalCalibrationStatus, int64(status))
+			}
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		err := g.Calibrate(ctx, true)
+
+		cancel()
+		g.stopCalibrationUpdates()
+
+		var calibrationErr *CalibrationError
+		if test.failed {
+			if !errors.As(err, &calibrationErr) {
+				t.Errorf("%s: expected *CalibrationError, got %v", test.name,
+					err)
+				continue
+			}
+			if calibrationErr.Progress != test.progress {
+				t.Errorf("%s: expected progress %d, got %d", test.name,
+					test.progress, calibrationErr.Progress)
+			}
+		} else if err != nil {
+			t.Errorf("%s: expected nil error, got %q", test.name, err)
+		}
+	}
+}
+
+func TestGimbal_Calibrate_Errors(t *testing.T) {
+	cc := servicetest.Install(t)
+
+	g := New(support.NewLogger(nil, nil, nil, nil))
+	g.startCalibrationUpdates()
+	defer g.stopCalibrationUpdates()
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel()
+
+	// The robot never reports a final status.
+	cc.OnAction(dji.DJIGimbalCalibration, func(dji.DJIParamValue) {
+		cc.Publish(dji.DJIGimbalCalibrationStatus,
+			int64(CalibrationStatusCalibrating))
+	})
+
+	err := g.Calibrate(ctx, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+
+	// The robot refuses to calibrate.
+	cc.SetErrorCode(dji.DJIGimbalCalibration, 1)
+
+	err = g.Calibrate(context.Background(), false)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
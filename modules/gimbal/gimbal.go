@@ -3,19 +3,38 @@ package gimbal
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/brunoga/robomaster2/internal/robot/service"
 	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/stream"
 	"github.com/brunoga/robomaster2/support"
 )
 
 type Gimbal struct {
 	logger *support.Logger
+
+	attitudeStream    *stream.Stream[Attitude]
+	resetStateStream  *stream.Stream[ResetPositionState]
+	calibrationStream *stream.Stream[CalibrationUpdate]
+
+	m               sync.Mutex
+	pitchRate       float64
+	yawRate         float64
+	lastSpeedUpdate time.Time
+	speedDeadman    time.Duration
+	speedSenderQuit chan struct{}
+	speedSenderDone chan struct{}
+	calibration     CalibrationUpdate
 }
 
 func New(logger *support.Logger) *Gimbal {
 	return &Gimbal{
-		logger,
+		logger:            logger,
+		attitudeStream:    stream.New[Attitude](),
+		resetStateStream:  stream.New[ResetPositionState](),
+		calibrationStream: stream.New[CalibrationUpdate](),
+		speedDeadman:      defaultSpeedDeadman,
 	}
 }
 
@@ -23,6 +42,7 @@ func (g *Gimbal) Start() {
 	cc := service.DJICommandControllerInstance()
 
 	connectionWg := sync.WaitGroup{}
+	connectionOnce := sync.Once{}
 
 	connectionWg.Add(1)
 	cc.StartListeningOnKey(dji.DJIGimbalConnection, g,
@@ -35,12 +55,26 @@ func (g *Gimbal) Start() {
 				fmt.Println("Gimbal connection failed.")
 			}
 
-			connectionWg.Done()
+			// Connection updates keep coming after the first one.
+			connectionOnce.Do(connectionWg.Done)
 		}, false)
 
 	connectionWg.Wait()
+
+	g.startAttitudeUpdates()
+	g.startResetStateUpdates()
+	g.startCalibrationUpdates()
 }
 
+// Stop stops any rate controlled rotation and gimbal state updates.
+func (g *Gimbal) Stop() {
+	g.stopSpeed()
+	g.stopCalibrationUpdates()
+	g.stopResetStateUpdates()
+	g.stopAttitudeUpdates()
+}
+
+// Deprecated: Use Move with MoveModeAbsolute.
 func (g *Gimbal) MoveToAbsoluteAngle(angle, axis int16, duration float32) {
 	if axis == 1 {
 		service.DJICommandControllerInstance().PerformActionWithParam(
@@ -56,6 +90,7 @@ func (g *Gimbal) MoveToAbsoluteAngle(angle, axis int16, duration float32) {
 			angle*10, 0, int16(duration*1000)), nil)
 }
 
+// Deprecated: Use Move with MoveModeRelative.
 func (g *Gimbal) MoveToRelativeAngle(angle, axis int16, duration float32) {
 	time := int16(duration * 1000)
 	yaw := int16(0)
@@ -69,8 +104,3 @@ func (g *Gimbal) MoveToRelativeAngle(angle, axis int16, duration float32) {
 		dji.DJIGimbalAngleIncrementRotation,
 		dji.NewDJIGimbalAngleRotationParamValue(pitch, yaw, time), nil)
 }
-
-func (g *Gimbal) Reset() {
-	service.DJICommandControllerInstance().PerformAction(
-		dji.DJIGimbalResetPosition, nil)
-}
@@ -0,0 +1,191 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+const (
+	// Time to wait for the robot to report it started recentering before
+	// assuming the gimbal was already centered.
+	resetStartTimeout = time.Second
+
+	// Maximum time recentering can take.
+	resetTimeout = 10 * time.Second
+)
+
+// WorkMode is how the gimbal behaves relative to the chassis.
+type WorkMode int64
+
+const (
+	// The gimbal keeps its heading independently of the chassis.
+	WorkModeFree WorkMode = iota
+
+	// First person view: the gimbal is locked to the chassis.
+	WorkModeFPV
+
+	// The gimbal yaw follows the chassis heading.
+	WorkModeYawFollow
+)
+
+func (w WorkMode) String() string {
+	switch w {
+	case WorkModeFree:
+		return "Free"
+	case WorkModeFPV:
+		return "FPV"
+	case WorkModeYawFollow:
+		return "YawFollow"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(w))
+}
+
+// ControlMode is how the gimbal interprets control input.
+type ControlMode int64
+
+const (
+	// Input sets gimbal angles.
+	ControlModeAngle ControlMode = iota
+
+	// Input sets gimbal rotation speeds.
+	ControlModeSpeed
+)
+
+func (c ControlMode) String() string {
+	switch c {
+	case ControlModeAngle:
+		return "Angle"
+	case ControlModeSpeed:
+		return "Speed"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(c))
+}
+
+// ResetPositionState is the state of gimbal recentering.
+type ResetPositionState int64
+
+const (
+	ResetPositionStateIdle ResetPositionState = iota
+	ResetPositionStateResetting
+)
+
+func (r ResetPositionState) String() string {
+	switch r {
+	case ResetPositionStateIdle:
+		return "Idle"
+	case ResetPositionStateResetting:
+		return "Resetting"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(r))
+}
+
+// WorkMode returns the current gimbal WorkMode. Returns the mode and a nil
+// error on success and WorkModeFree and a non-nil error on failure.
+func (g *Gimbal) WorkMode(ctx context.Context) (WorkMode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIGimbalWorkMode)
+
+	return WorkMode(value), err
+}
+
+// SetWorkMode sets the gimbal WorkMode. Returns a nil error on success and a
+// non-nil error on failure.
+func (g *Gimbal) SetWorkMode(ctx context.Context, mode WorkMode) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIGimbalWorkMode, int64(mode))
+}
+
+// ControlMode returns the current gimbal ControlMode. Returns the mode and a
+// nil error on success and ControlModeAngle and a non-nil error on failure.
+func (g *Gimbal) ControlMode(ctx context.Context) (ControlMode, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIGimbalControlMode)
+
+	return ControlMode(value), err
+}
+
+// SetControlMode sets the gimbal ControlMode. Returns a nil error on success
+// and a non-nil error on failure.
+func (g *Gimbal) SetControlMode(ctx context.Context, mode ControlMode) error {
+	return service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIGimbalControlMode,
+		int64(mode))
+}
+
+// Reset starts recentering the gimbal and returns immediately. Use Recenter to
+// wait until recentering finishes.
+func (g *Gimbal) Reset() {
+	service.DJICommandControllerInstance().PerformAction(
+		dji.DJIGimbalResetPosition, nil)
+}
+
+// Recenter recenters the gimbal and waits until recentering finishes. Returns
+// a nil error on success and a non-nil error on failure (including the context
+// being done before recentering finishes).
+func (g *Gimbal) Recenter(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, resetTimeout)
+	defer cancel()
+
+	// Subscribe before resetting so no state change is missed.
+	stateChan := g.resetStateStream.Subscribe(ctx)
+
+	_, err := service.PerformActionSync(ctx,
+		service.DJICommandControllerInstance(), dji.DJIGimbalResetPosition,
+		nil)
+	if err != nil {
+		return err
+	}
+
+	startTimer := time.NewTimer(resetStartTimeout)
+	defer startTimer.Stop()
+
+	resetting := false
+	for {
+		select {
+		case state, ok := <-stateChan:
+			if !ok {
+				return fmt.Errorf("error recentering gimbal: %w", ctx.Err())
+			}
+
+			if state == ResetPositionStateResetting {
+				resetting = true
+			} else if resetting {
+				return nil
+			}
+		case <-startTimer.C:
+			if !resetting {
+				// Recentering never started. The gimbal was already
+				// centered.
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("error recentering gimbal: %w", ctx.Err())
+		}
+	}
+}
+
+func (g *Gimbal) startResetStateUpdates() {
+	service.DJICommandControllerInstance().StartListeningOnKey(
+		dji.DJIGimbalResetPositionState, g, g.onResetState, false)
+}
+
+func (g *Gimbal) stopResetStateUpdates() {
+	service.DJICommandControllerInstance().StopListeningOnKey(
+		dji.DJIGimbalResetPositionState, g)
+}
+
+func (g *Gimbal) onResetState(result *dji.DJIResult) {
+	var value int64
+	if !service.DecodeResult(g.logger, result, &value) {
+		return
+	}
+
+	g.resetStateStream.Publish(ResetPositionState(value))
+}
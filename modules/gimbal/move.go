@@ -0,0 +1,297 @@
+package gimbal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+const (
+	// Absolute pitch limits, in degrees.
+	MinPitch = -20
+	MaxPitch = 35
+
+	// Absolute yaw limits relative to the chassis, in degrees.
+	MinYaw = -250
+	MaxYaw = 250
+
+	// Maximum move duration. Durations are sent to the robot in milliseconds
+	// as 16 bit integers.
+	MaxMoveDuration = math.MaxInt16 * time.Millisecond
+
+	// A move is considered complete when the attitude is within this many
+	// degrees from the target.
+	moveTolerance = 1.0
+
+	// Extra time, on top of the move duration, to wait for the attitude to
+	// converge.
+	moveConvergenceGrace = time.Second
+)
+
+var (
+	// ErrMoveNotConverged is returned by Move when the gimbal attitude does
+	// not reach the target in time.
+	ErrMoveNotConverged = errors.New("gimbal did not reach target")
+)
+
+// Axis identifies gimbal axes. Axes can be combined.
+type Axis int
+
+const (
+	AxisPitch Axis = 1 << iota
+	AxisYaw
+
+	AxisBoth = AxisPitch | AxisYaw
+)
+
+func (a Axis) String() string {
+	switch a {
+	case AxisPitch:
+		return "Pitch"
+	case AxisYaw:
+		return "Yaw"
+	case AxisBoth:
+		return "Both"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int(a))
+}
+
+// MoveMode is how the angles in a Target are interpreted.
+type MoveMode int
+
+const (
+	// Angles are absolute: pitch relative to the horizon and yaw relative to
+	// the chassis front.
+	MoveModeAbsolute MoveMode = iota
+
+	// Angles are increments relative to the current attitude.
+	MoveModeRelative
+)
+
+func (m MoveMode) String() string {
+	switch m {
+	case MoveModeAbsolute:
+		return "Absolute"
+	case MoveModeRelative:
+		return "Relative"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int(m))
+}
+
+// Target describes a gimbal move. Pitch and Yaw are in degrees (positive is
+// up and clockwise, respectively) and Duration is how long the move should
+// take. Only the given Axes are moved (zero means both).
+type Target struct {
+	Pitch    float64
+	Yaw      float64
+	Duration time.Duration
+	Mode     MoveMode
+	Axes     Axis
+}
+
+// Validate checks if the target can be sent to the robot. Returns a nil error
+// if it can and a non-nil error otherwise.
+func (t Target) Validate() error {
+	if t.Duration <= 0 || t.Duration > MaxMoveDuration {
+		return fmt.Errorf("move duration must be in the (0, %s] range",
+			MaxMoveDuration)
+	}
+
+	if t.Axes&^AxisBoth != 0 {
+		return fmt.Errorf("invalid axes %d", int(t.Axes))
+	}
+
+	switch t.Mode {
+	case MoveModeAbsolute:
+		if t.Pitch < MinPitch || t.Pitch > MaxPitch {
+			return fmt.Errorf("pitch must be in the [%d, %d] range",
+				MinPitch, MaxPitch)
+		}
+		if t.Yaw < MinYaw || t.Yaw > MaxYaw {
+			return fmt.Errorf("yaw must be in the [%d, %d] range", MinYaw,
+				MaxYaw)
+		}
+	case MoveModeRelative:
+		if math.Abs(t.Pitch) > MaxPitch-MinPitch {
+			return fmt.Errorf("pitch increment must be at most %d degrees",
+				MaxPitch-MinPitch)
+		}
+		if math.Abs(t.Yaw) > MaxYaw-MinYaw {
+			return fmt.Errorf("yaw increment must be at most %d degrees",
+				MaxYaw-MinYaw)
+		}
+	default:
+		return fmt.Errorf("invalid move mode %d", int(t.Mode))
+	}
+
+	return nil
+}
+
+// Move moves the gimbal to the given target. It returns when the robot
+// reports the move failed (non-nil error) or when the gimbal attitude
+// converges to the target (nil error). If there are no attitude updates, it
+// returns as soon as the robot accepts the move. Returns ErrMoveNotConverged
+// if the attitude does not converge in time and a non-nil error if the given
+// context is done before the move completes.
+func (g *Gimbal) Move(ctx context.Context, target Target) error {
+	err := target.Validate()
+	if err != nil {
+		return err
+	}
+
+	start, hasAttitude := g.Attitude()
+
+	cc := service.DJICommandControllerInstance()
+
+	keys, values := target.actions()
+
+	resultChan := make(chan *dji.DJIResult, len(keys))
+	for i, key := range keys {
+		cc.PerformActionWithParam(key, values[i],
+			func(result *dji.DJIResult) {
+				resultChan <- result
+			})
+	}
+
+	for range keys {
+		select {
+		case result := <-resultChan:
+			if !result.Succeeded() {
+				return fmt.Errorf("error moving gimbal: %d (%s)",
+					result.ErrorCode(), result.ErrorDesc())
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("error moving gimbal: %w", ctx.Err())
+		}
+	}
+
+	if !hasAttitude {
+		return nil
+	}
+
+	targetPitch, targetYaw := target.attitude(start)
+
+	return g.waitForAttitude(ctx, targetPitch, targetYaw,
+		target.Duration+moveConvergenceGrace)
+}
+
+// waitForAttitude waits until the gimbal pitch and chassis relative yaw are
+// within tolerance from the given ones (NaN means any value). Returns a nil
+// error on success and a non-nil error on failure.
+func (g *Gimbal) waitForAttitude(ctx context.Context, pitch, yaw float64,
+	timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attitudeChan := g.SubscribeAttitude(ctx)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	converged := func(attitude Attitude) bool {
+		return attitudeConverged(attitude, pitch, yaw)
+	}
+
+	if attitude, ok := g.Attitude(); ok && converged(attitude) {
+		return nil
+	}
+
+	for {
+		select {
+		case attitude := <-attitudeChan:
+			if converged(attitude) {
+				return nil
+			}
+		case <-timer.C:
+			return ErrMoveNotConverged
+		case <-ctx.Done():
+			return fmt.Errorf("error moving gimbal: %w", ctx.Err())
+		}
+	}
+}
+
+// axes returns the axes moved by the target.
+func (t Target) axes() Axis {
+	if t.Axes == 0 {
+		return AxisBoth
+	}
+
+	return t.Axes
+}
+
+// actions returns the action keys and values that move the gimbal to the
+// target.
+func (t Target) actions() ([]dji.DJIKeys, []dji.DJIParamValue) {
+	axes := t.axes()
+
+	pitch := int16(math.Round(t.Pitch * 10))
+	yaw := int16(math.Round(t.Yaw * 10))
+	duration := int16(t.Duration / time.Millisecond)
+
+	var keys []dji.DJIKeys
+	var values []dji.DJIParamValue
+
+	if t.Mode == MoveModeRelative {
+		if axes&AxisPitch == 0 {
+			pitch = 0
+		}
+		if axes&AxisYaw == 0 {
+			yaw = 0
+		}
+
+		keys = append(keys, dji.DJIGimbalAngleIncrementRotation)
+		values = append(values,
+			dji.NewDJIGimbalAngleRotationParamValue(pitch, yaw, duration))
+	} else {
+		if axes&AxisPitch != 0 {
+			keys = append(keys, dji.DJIGimbalAngleFrontPitchRotation)
+			values = append(values,
+				dji.NewDJIGimbalAngleRotationParamValue(pitch, 0, duration))
+		}
+		if axes&AxisYaw != 0 {
+			keys = append(keys, dji.DJIGimbalAngleFrontYawRotation)
+			values = append(values,
+				dji.NewDJIGimbalAngleRotationParamValue(0, yaw, duration))
+		}
+	}
+
+	return keys, values
+}
+
+// attitude returns the pitch and chassis relative yaw the gimbal should reach
+// when moving to the target from the given attitude. Axes that are not moved
+// are NaN. Yaw is chassis relative as that is what absolute moves refer to.
+func (t Target) attitude(start Attitude) (pitch, yaw float64) {
+	axes := t.axes()
+
+	pitch = t.Pitch
+	yaw = t.Yaw
+	if t.Mode == MoveModeRelative {
+		pitch += start.Pitch
+		yaw += start.ChassisYaw
+	}
+	if axes&AxisPitch == 0 {
+		pitch = math.NaN()
+	}
+	if axes&AxisYaw == 0 {
+		yaw = math.NaN()
+	}
+
+	return pitch, yaw
+}
+
+// attitudeConverged returns true if the given attitude pitch and chassis
+// relative yaw are within tolerance from the given ones (NaN means any value).
+func attitudeConverged(attitude Attitude, pitch, yaw float64) bool {
+	return (math.IsNaN(pitch) ||
+		math.Abs(attitude.Pitch-pitch) <= moveTolerance) &&
+		(math.IsNaN(yaw) ||
+			math.Abs(attitude.ChassisYaw-yaw) <= moveTolerance)
+}
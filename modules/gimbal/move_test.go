@@ -0,0 +1,169 @@
+package gimbal
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+func TestTarget_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		valid  bool
+	}{
+		{"Absolute", Target{Pitch: 10, Yaw: -90, Duration: time.Second},
+			true},
+		{"AbsoluteLimits", Target{Pitch: MaxPitch, Yaw: MinYaw,
+			Duration: MaxMoveDuration}, true},
+		{"Relative", Target{Pitch: -55, Yaw: 500, Duration: time.Second,
+			Mode: MoveModeRelative}, true},
+		{"PitchAxis", Target{Pitch: 10, Duration: time.Second,
+			Axes: AxisPitch}, true},
+		{"ZeroDuration", Target{}, false},
+		{"NegativeDuration", Target{Duration: -time.Second}, false},
+		{"LongDuration", Target{Duration: MaxMoveDuration +
+			time.Millisecond}, false},
+		{"InvalidAxes", Target{Duration: time.Second, Axes: 4}, false},
+		{"PitchTooHigh", Target{Pitch: MaxPitch + 1,
+			Duration: time.Second}, false},
+		{"PitchTooLow", Target{Pitch: MinPitch - 1,
+			Duration: time.Second}, false},
+		{"YawTooHigh", Target{Yaw: MaxYaw + 1, Duration: time.Second},
+			false},
+		{"PitchIncrementTooLarge", Target{Pitch: MaxPitch - MinPitch + 1,
+			Duration: time.Second, Mode: MoveModeRelative}, false},
+		{"YawIncrementTooLarge", Target{Yaw: MinYaw - MaxYaw - 1,
+			Duration: time.Second, Mode: MoveModeRelative}, false},
+		{"InvalidMode", Target{Duration: time.Second, Mode: 2}, false},
+	}
+
+	for _, test := range tests {
+		err := test.target.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected nil error, got %q", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected non-nil error, got nil", test.name)
+		}
+	}
+}
+
+func TestTarget_Actions(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		keys   []dji.DJIKeys
+		values []dji.DJIParamValue
+	}{
+		{
+			"AbsoluteBoth",
+			Target{Pitch: 10.04, Yaw: -20.06, Duration: time.Second},
+			[]dji.DJIKeys{dji.DJIGimbalAngleFrontPitchRotation,
+				dji.DJIGimbalAngleFrontYawRotation},
+			[]dji.DJIParamValue{
+				dji.NewDJIGimbalAngleRotationParamValue(100, 0, 1000),
+				dji.NewDJIGimbalAngleRotationParamValue(0, -201, 1000),
+			},
+		},
+		{
+			"AbsoluteYaw",
+			Target{Pitch: 10, Yaw: 20, Duration: 500 * time.Millisecond,
+				Axes: AxisYaw},
+			[]dji.DJIKeys{dji.DJIGimbalAngleFrontYawRotation},
+			[]dji.DJIParamValue{
+				dji.NewDJIGimbalAngleRotationParamValue(0, 200, 500),
+			},
+		},
+		{
+			"RelativeBoth",
+			Target{Pitch: -5, Yaw: 30, Duration: time.Second,
+				Mode: MoveModeRelative},
+			[]dji.DJIKeys{dji.DJIGimbalAngleIncrementRotation},
+			[]dji.DJIParamValue{
+				dji.NewDJIGimbalAngleRotationParamValue(-50, 300, 1000),
+			},
+		},
+		{
+			"RelativePitch",
+			Target{Pitch: -5, Yaw: 30, Duration: time.Second,
+				Mode: MoveModeRelative, Axes: AxisPitch},
+			[]dji.DJIKeys{dji.DJIGimbalAngleIncrementRotation},
+			[]dji.DJIParamValue{
+				dji.NewDJIGimbalAngleRotationParamValue(-50, 0, 1000),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		keys, values := test.target.actions()
+		if !reflect.DeepEqual(keys, test.keys) {
+			t.Errorf("%s: expected keys %v, got %v", test.name, test.keys,
+				keys)
+		}
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%s: expected values %+v, got %+v", test.name,
+				test.values, values)
+		}
+	}
+}
+
+func TestTarget_Attitude(t *testing.T) {
+	start := Attitude{Pitch: 5, Yaw: 100, ChassisYaw: 10}
+
+	tests := []struct {
+		name   string
+		target Target
+		pitch  float64
+		yaw    float64
+	}{
+		{"Absolute", Target{Pitch: 20, Yaw: -30}, 20, -30},
+		{"Relative", Target{Pitch: 20, Yaw: -30, Mode: MoveModeRelative},
+			25, -20},
+		{"PitchOnly", Target{Pitch: 20, Yaw: -30, Axes: AxisPitch}, 20,
+			math.NaN()},
+		{"YawOnly", Target{Pitch: 20, Yaw: -30, Mode: MoveModeRelative,
+			Axes: AxisYaw}, math.NaN(), -20},
+	}
+
+	sameAngle := func(a, b float64) bool {
+		return (math.IsNaN(a) && math.IsNaN(b)) || a == b
+	}
+
+	for _, test := range tests {
+		pitch, yaw := test.target.attitude(start)
+		if !sameAngle(pitch, test.pitch) || !sameAngle(yaw, test.yaw) {
+			t.Errorf("%s: expected (%f, %f), got (%f, %f)", test.name,
+				test.pitch, test.yaw, pitch, yaw)
+		}
+	}
+}
+
+func TestAttitudeConverged(t *testing.T) {
+	attitude := Attitude{Pitch: 10, Yaw: 100, ChassisYaw: 20}
+
+	tests := []struct {
+		pitch     float64
+		yaw       float64
+		converged bool
+	}{
+		{10, 20, true},
+		{10.5, 19.5, true},
+		{12, 20, false},
+		{10, 100, false}, // Gimbal yaw is not chassis relative.
+		{math.NaN(), 20, true},
+		{10, math.NaN(), true},
+		{math.NaN(), 30, false},
+	}
+
+	for _, test := range tests {
+		got := attitudeConverged(attitude, test.pitch, test.yaw)
+		if got != test.converged {
+			t.Errorf("(%f, %f): expected %t, got %t", test.pitch, test.yaw,
+				test.converged, got)
+		}
+	}
+}
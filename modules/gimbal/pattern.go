@@ -0,0 +1,371 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Default duration of each move in a pattern.
+	defaultPatternMoveDuration = 500 * time.Millisecond
+
+	// Default rate at which tracking errors are sampled.
+	defaultTrackInterval = 50 * time.Millisecond
+)
+
+// Pattern is a sequence of gimbal movements.
+type Pattern interface {
+	// Run executes the pattern on the given gimbal. Returns a nil error when
+	// the pattern completes and a non-nil error on failure (including the
+	// context being done before it completes).
+	Run(ctx context.Context, g *Gimbal) error
+}
+
+// PatternFunc adapts a function to the Pattern interface.
+type PatternFunc func(ctx context.Context, g *Gimbal) error
+
+// Run calls f(ctx, g).
+func (f PatternFunc) Run(ctx context.Context, g *Gimbal) error {
+	return f(ctx, g)
+}
+
+// RunPattern executes the given pattern. Returns a nil error when the pattern
+// completes and a non-nil error on failure.
+func (g *Gimbal) RunPattern(ctx context.Context, pattern Pattern) error {
+	return pattern.Run(ctx, g)
+}
+
+// Sequence returns a Pattern that runs the given patterns one after the
+// other.
+func Sequence(patterns ...Pattern) Pattern {
+	return PatternFunc(func(ctx context.Context, g *Gimbal) error {
+		for _, pattern := range patterns {
+			err := pattern.Run(ctx, g)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RasterScan scans an area row by row, alternating the yaw direction on each
+// row. Angles are absolute, in degrees.
+type RasterScan struct {
+	MinPitch  float64
+	MaxPitch  float64
+	PitchStep float64
+	MinYaw    float64
+	MaxYaw    float64
+	YawStep   float64
+
+	// Duration of each move (zero means a default) and time to stay at each
+	// position.
+	MoveDuration time.Duration
+	Dwell        time.Duration
+}
+
+// Run implements Pattern.
+func (r RasterScan) Run(ctx context.Context, g *Gimbal) error {
+	if r.PitchStep <= 0 || r.YawStep <= 0 {
+		return fmt.Errorf("raster scan steps must be positive")
+	}
+
+	pitches := steps(r.MaxPitch, r.MinPitch, r.PitchStep)
+	yaws := steps(r.MinYaw, r.MaxYaw, r.YawStep)
+
+	for row, pitch := range pitches {
+		for i := range yaws {
+			yaw := yaws[i]
+			if row%2 == 1 {
+				yaw = yaws[len(yaws)-1-i]
+			}
+
+			err := g.Move(ctx, Target{
+				Pitch:    pitch,
+				Yaw:      yaw,
+				Duration: moveDuration(r.MoveDuration),
+				Mode:     MoveModeAbsolute,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = sleep(ctx, r.Dwell)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Sweep moves the gimbal yaw back and forth between limits at a fixed pitch.
+// Angles are absolute, in degrees.
+type Sweep struct {
+	Pitch  float64
+	MinYaw float64
+	MaxYaw float64
+
+	// Time a full back and forth cycle takes.
+	Period time.Duration
+
+	// Number of cycles. Zero means sweeping until the context is done.
+	Count int
+}
+
+// Run implements Pattern.
+func (s Sweep) Run(ctx context.Context, g *Gimbal) error {
+	if s.Period <= 0 {
+		return fmt.Errorf("sweep period must be positive")
+	}
+
+	for i := 0; s.Count == 0 || i < s.Count; i++ {
+		for _, yaw := range []float64{s.MinYaw, s.MaxYaw} {
+			err := g.Move(ctx, Target{
+				Pitch:    s.Pitch,
+				Yaw:      yaw,
+				Duration: s.Period / 2,
+				Mode:     MoveModeAbsolute,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LookAt points the gimbal at a point in the chassis frame. X is forward, Y is
+// to the right and Z is up, in meters, with the origin at the gimbal rotation
+// center.
+type LookAt struct {
+	X float64
+	Y float64
+	Z float64
+
+	// Duration of the move (zero means a default).
+	Duration time.Duration
+}
+
+// Angles returns the absolute pitch and yaw, in degrees, needed to look at the
+// point.
+func (l LookAt) Angles() (pitch, yaw float64) {
+	yaw = math.Atan2(l.Y, l.X) * 180 / math.Pi
+	pitch = math.Atan2(l.Z, math.Hypot(l.X, l.Y)) * 180 / math.Pi
+
+	return pitch, yaw
+}
+
+// Run implements Pattern.
+func (l LookAt) Run(ctx context.Context, g *Gimbal) error {
+	pitch, yaw := l.Angles()
+
+	return g.Move(ctx, Target{
+		Pitch:    math.Max(MinPitch, math.Min(MaxPitch, pitch)),
+		Yaw:      yaw,
+		Duration: moveDuration(l.Duration),
+		Mode:     MoveModeAbsolute,
+	})
+}
+
+// TrackingFunc returns the position error of a tracked target in image space,
+// normalized to [-1, 1] (x to the right and y down, 0 at the image center),
+// and true if the target is visible or false otherwise.
+type TrackingFunc func() (x, y float64, visible bool)
+
+// Track keeps a target centered in the image by driving the gimbal speed with
+// a PID controller per axis. When the target is not visible for LostTimeout,
+// the Search pattern (if any) runs until it is visible again. Tracking goes on
+// until the context is done.
+type Track struct {
+	Target TrackingFunc
+
+	// Controllers converting the normalized image error to degrees/s.
+	Pitch PID
+	Yaw   PID
+
+	// Sampling interval (zero means a default).
+	Interval time.Duration
+
+	LostTimeout time.Duration
+	Search      Pattern
+}
+
+// Run implements Pattern.
+func (t Track) Run(ctx context.Context, g *Gimbal) error {
+	interval := t.Interval
+	if interval <= 0 {
+		interval = defaultTrackInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	defer g.stopSpeed()
+
+	lastSeen := time.Now()
+	var searchCancel context.CancelFunc
+	var searchDone chan error
+
+	stopSearch := func() {
+		if searchCancel != nil {
+			searchCancel()
+			<-searchDone
+			searchCancel = nil
+		}
+	}
+	defer stopSearch()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("error tracking target: %w", ctx.Err())
+		case err := <-searchDone:
+			// The search pattern finished without finding the target.
+			searchCancel()
+			searchCancel = nil
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			lastSeen = time.Now()
+		case now := <-ticker.C:
+			x, y, visible := t.Target()
+			if !visible {
+				t.Pitch.Reset()
+				t.Yaw.Reset()
+
+				if searchCancel != nil {
+					// Already searching.
+					continue
+				}
+
+				g.stopSpeed()
+
+				if t.Search != nil && now.Sub(lastSeen) > t.LostTimeout {
+					searchCtx, cancel := context.WithCancel(ctx)
+					searchCancel = cancel
+					searchDone = make(chan error, 1)
+					go func(done chan error) {
+						done <- t.Search.Run(searchCtx, g)
+					}(searchDone)
+				}
+
+				continue
+			}
+
+			lastSeen = now
+			stopSearch()
+
+			dt := interval.Seconds()
+			pitchRate := t.Pitch.Update(-y, dt)
+			yawRate := t.Yaw.Update(x, dt)
+
+			err := g.SetSpeed(clampSpeed(pitchRate), clampSpeed(yawRate))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PID is a proportional-integral-derivative controller.
+type PID struct {
+	Kp float64
+	Ki float64
+	Kd float64
+
+	// Maximum absolute value of the integral term contribution. Zero means no
+	// limit.
+	IntegralLimit float64
+
+	integral    float64
+	previousErr float64
+	hasPrevious bool
+}
+
+// Update returns the controller output for the given error, dt seconds after
+// the previous update.
+func (p *PID) Update(err, dt float64) float64 {
+	p.integral += err * dt
+	if p.IntegralLimit > 0 && p.Ki != 0 {
+		limit := p.IntegralLimit / math.Abs(p.Ki)
+		p.integral = math.Max(-limit, math.Min(limit, p.integral))
+	}
+
+	derivative := 0.0
+	if p.hasPrevious && dt > 0 {
+		derivative = (err - p.previousErr) / dt
+	}
+
+	p.previousErr = err
+	p.hasPrevious = true
+
+	return p.Kp*err + p.Ki*p.integral + p.Kd*derivative
+}
+
+// Reset clears the controller state.
+func (p *PID) Reset() {
+	p.integral = 0
+	p.previousErr = 0
+	p.hasPrevious = false
+}
+
+// steps returns the values from start to end (inclusive), step apart. Values
+// within rounding error of end are replaced by it, so end is not repeated.
+func steps(start, end, step float64) []float64 {
+	if end < start {
+		step = -step
+	}
+
+	// Tolerance for rounding errors, relative to the step.
+	tolerance := math.Abs(step) * 1e-9
+
+	n := int(math.Floor((math.Abs(end-start)+tolerance)/math.Abs(step))) + 1
+
+	values := make([]float64, 0, n+1)
+	for i := 0; i < n; i++ {
+		values = append(values, start+float64(i)*step)
+	}
+
+	if last := len(values) - 1; math.Abs(values[last]-end) <= tolerance {
+		values[last] = end
+	} else {
+		values = append(values, end)
+	}
+
+	return values
+}
+
+func moveDuration(duration time.Duration) time.Duration {
+	if duration <= 0 {
+		return defaultPatternMoveDuration
+	}
+
+	return duration
+}
+
+func clampSpeed(speed float64) float64 {
+	return math.Max(-MaxSpeed, math.Min(MaxSpeed, speed))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error running pattern: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,125 @@
+package gimbal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPID_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		pid      PID
+		errs     []float64
+		dt       float64
+		expected []float64
+	}{
+		{"Proportional", PID{Kp: 2}, []float64{0.5, -0.25}, 0.1,
+			[]float64{1, -0.5}},
+		{"Integral", PID{Ki: 1}, []float64{1, 1, 1}, 0.5,
+			[]float64{0.5, 1, 1.5}},
+		// No derivative on the first update.
+		{"Derivative", PID{Kd: 1}, []float64{1, 2, 2}, 0.5,
+			[]float64{0, 2, 0}},
+		{"IntegralLimit", PID{Ki: 2, IntegralLimit: 1}, []float64{1, 1, -1},
+			1, []float64{1, 1, -1}},
+		{"Combined", PID{Kp: 1, Ki: 1, Kd: 1}, []float64{1, 0}, 1,
+			[]float64{2, 0}},
+		{"ZeroDt", PID{Kp: 1, Kd: 1}, []float64{1, 2}, 0,
+			[]float64{1, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pid := test.pid
+			for i, err := range test.errs {
+				got := pid.Update(err, test.dt)
+				if math.Abs(got-test.expected[i]) > 1e-9 {
+					t.Fatalf("update %d: expected %f, got %f", i,
+						test.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestPID_Reset(t *testing.T) {
+	pid := PID{Kp: 1, Ki: 1, Kd: 1}
+
+	pid.Update(1, 1)
+	pid.Update(3, 1)
+
+	pid.Reset()
+
+	// Same as the first update on a new controller.
+	got := pid.Update(1, 1)
+	if got != 2 {
+		t.Fatalf("expected 2, got %f", got)
+	}
+}
+
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    float64
+		end      float64
+		step     float64
+		expected []float64
+	}{
+		{"Exact", 0, 30, 10, []float64{0, 10, 20, 30}},
+		{"Partial", 0, 25, 10, []float64{0, 10, 20, 25}},
+		{"Descending", 10, -20, 10, []float64{10, 0, -10, -20}},
+		{"DescendingPartial", 10, -5, 10, []float64{10, 0, -5}},
+		{"Single", 5, 5, 10, []float64{5}},
+		{"StepLargerThanRange", 0, 5, 10, []float64{0, 5}},
+		{"FloatEnd", 0, 0.9, 0.3, []float64{0, 0.3, 0.6, 0.9}},
+		{"FloatEndShort", 0, 0.3, 0.1, []float64{0, 0.1, 0.2, 0.3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := steps(test.start, test.end, test.step)
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, got)
+			}
+
+			for i := range got {
+				if math.Abs(got[i]-test.expected[i]) > 1e-9 {
+					t.Fatalf("expected %v, got %v", test.expected, got)
+				}
+			}
+
+			if got[len(got)-1] != test.end {
+				t.Fatalf("expected last value %v, got %v", test.end,
+					got[len(got)-1])
+			}
+		})
+	}
+}
+
+func TestLookAt_Angles(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookAt LookAt
+		pitch  float64
+		yaw    float64
+	}{
+		{"Forward", LookAt{X: 1}, 0, 0},
+		{"Right", LookAt{Y: 1}, 0, 90},
+		{"Left", LookAt{Y: -1}, 0, -90},
+		{"Behind", LookAt{X: -1}, 0, 180},
+		{"Up", LookAt{X: 1, Z: 1}, 45, 0},
+		{"Down", LookAt{X: 1, Z: -1}, -45, 0},
+		{"Diagonal", LookAt{X: 1, Y: 1, Z: math.Sqrt2}, 45, 45},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pitch, yaw := test.lookAt.Angles()
+			if math.Abs(pitch-test.pitch) > 1e-9 ||
+				math.Abs(yaw-test.yaw) > 1e-9 {
+				t.Fatalf("expected (%f, %f), got (%f, %f)", test.pitch,
+					test.yaw, pitch, yaw)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package gimbal
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+const (
+	// Rate at which speed commands are sent to the robot while the gimbal is
+	// being rate controlled.
+	speedSendInterval = 50 * time.Millisecond
+
+	// Default time without SetSpeed calls after which the gimbal is stopped.
+	defaultSpeedDeadman = 500 * time.Millisecond
+
+	// Maximum gimbal rotation speed, in degrees/s.
+	MaxSpeed = 540
+)
+
+// SetSpeed rotates the gimbal at the given rates, in degrees/s. Positive
+// pitch rates move the gimbal up and positive yaw rates move it clockwise
+// (to the right). Speed rotation is enabled on the robot as needed and
+// disabled again when both rates are zero. If SetSpeed is not called again
+// within the deadman timeout (see SetSpeedDeadman), the gimbal is stopped.
+// Returns a nil error on success and a non-nil error on failure.
+func (g *Gimbal) SetSpeed(pitchRate, yawRate float64) error {
+	if math.Abs(pitchRate) > MaxSpeed || math.Abs(yawRate) > MaxSpeed {
+		return fmt.Errorf("gimbal speed must be at most %d degrees/s",
+			MaxSpeed)
+	}
+
+	if pitchRate == 0 && yawRate == 0 {
+		g.stopSpeed()
+		return nil
+	}
+
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	g.pitchRate = pitchRate
+	g.yawRate = yawRate
+	g.lastSpeedUpdate = time.Now()
+
+	if g.speedSenderQuit == nil {
+		g.setSpeedRotationEnabled(true)
+
+		g.speedSenderQuit = make(chan struct{})
+		g.speedSenderDone = make(chan struct{})
+
+		go g.speedSenderLoop(g.speedSenderQuit, g.speedSenderDone)
+	}
+
+	return nil
+}
+
+// SetSpeedDeadman sets for how long the gimbal keeps rotating without calls
+// to SetSpeed before it is stopped. Zero disables the deadman.
+func (g *Gimbal) SetSpeedDeadman(timeout time.Duration) {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	g.speedDeadman = timeout
+}
+
+// stopSpeed stops any rate controlled rotation and disables speed rotation on
+// the robot.
+func (g *Gimbal) stopSpeed() {
+	g.m.Lock()
+	g.pitchRate = 0
+	g.yawRate = 0
+	speedSenderQuit := g.speedSenderQuit
+	speedSenderDone := g.speedSenderDone
+	g.speedSenderQuit = nil
+	g.speedSenderDone = nil
+	g.m.Unlock()
+
+	if speedSenderQuit == nil {
+		return
+	}
+
+	close(speedSenderQuit)
+	<-speedSenderDone
+
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	// A SetSpeed call while we were waiting might have started rotating
+	// again. Leave it alone in that case.
+	if g.speedSenderQuit == nil {
+		g.sendSpeed(0, 0)
+		g.setSpeedRotationEnabled(false)
+	}
+}
+
+func (g *Gimbal) speedSenderLoop(quitChan, doneChan chan struct{}) {
+	defer close(doneChan)
+
+	ticker := time.NewTicker(speedSendInterval)
+	defer ticker.Stop()
+
+	for {
+		g.m.Lock()
+		pitchRate := g.pitchRate
+		yawRate := g.yawRate
+		expired := g.speedDeadman > 0 &&
+			time.Since(g.lastSpeedUpdate) > g.speedDeadman
+		if expired {
+			if g.speedSenderQuit == quitChan {
+				// The controller went quiet. Stop rotating. This is done
+				// before giving up ownership so a new SetSpeed can not
+				// enable speed rotation before we disable it.
+				g.pitchRate = 0
+				g.yawRate = 0
+				g.sendSpeed(0, 0)
+				g.setSpeedRotationEnabled(false)
+				g.speedSenderQuit = nil
+				g.speedSenderDone = nil
+			}
+
+			// Otherwise stopSpeed is already stopping us.
+			g.m.Unlock()
+			return
+		}
+		g.m.Unlock()
+
+		g.sendSpeed(pitchRate, yawRate)
+
+		select {
+		case <-ticker.C:
+		case <-quitChan:
+			return
+		}
+	}
+}
+
+func (g *Gimbal) sendSpeed(pitchRate, yawRate float64) {
+	service.DJICommandControllerInstance().PerformActionWithParam(
+		dji.DJIGimbalSpeedRotation,
+		dji.NewDJIGimbalSpeedRotationParamValue(int16(pitchRate*10),
+			int16(yawRate*10)), nil)
+}
+
+func (g *Gimbal) setSpeedRotationEnabled(enabled bool) {
+	service.DJICommandControllerInstance().SetValueForKey(
+		dji.DJIGimbalSpeedRotationEnabled, dji.NewDJIBoolParamValue(enabled),
+		func(result *dji.DJIResult) {
+			if !result.Succeeded() {
+				g.logger.WARNING("Error setting %s: %s",
+					dji.DJIGimbalSpeedRotationEnabled, result.ErrorDesc())
+			}
+		})
+}
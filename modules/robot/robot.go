@@ -1,51 +1,54 @@
 package robot
 
 import (
+	"sync"
+	"time"
+
 	"github.com/brunoga/robomaster2/internal/robot/service"
 	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/modules/robot/virtualstick"
 	"github.com/brunoga/robomaster2/support"
 )
 
 type Robot struct {
 	logger *support.Logger
+
+	m            sync.Mutex
+	sticks       virtualstick.Sticks
+	lastUpdate   time.Time
+	sendInterval time.Duration
+	deadman      time.Duration
+	senderQuit   chan struct{}
+	senderDone   chan struct{}
 }
 
 func NewRobot(logger *support.Logger) *Robot {
 	return &Robot{
-		logger,
+		logger:       logger,
+		sendInterval: defaultSendInterval,
+		deadman:      defaultDeadman,
 	}
 }
 
+// SendPose sends a single virtual stick update to the robot. Use SetSticks for
+// continuous control.
 func (r *Robot) SendPose(leftVertical, leftHorizontal, rightVertical,
 	rightHorizontal float32, isLeftCtl, isRightCtl bool, ctrlMode uint64) {
-	leftVerticalVal := int64(lerp(-660, 660, (leftVertical+1)*0.5) + 1024)
-	leftHorizontalVal := uint64(lerp(-660, 660, (leftHorizontal+1)*0.5) + 1024)
-	leftCtlVal := uint64(0)
-	if isLeftCtl {
-		leftCtlVal = 1
-	}
-
-	rightVerticalVal := uint64(lerp(-660, 660, (rightVertical+1)*0.5) + 1024)
-	rightHorizontalVal := uint64(lerp(-660, 660, (rightHorizontal+1)*0.5) + 1024)
-	rightCtlVal := uint64(0)
-	if isRightCtl {
-		rightCtlVal = 1
-	}
+	r.sendSticks(virtualstick.Sticks{
+		LeftVertical:    leftVertical,
+		LeftHorizontal:  leftHorizontal,
+		RightVertical:   rightVertical,
+		RightHorizontal: rightHorizontal,
+		LeftTouched:     isLeftCtl,
+		RightTouched:    isRightCtl,
+		CtrlMode:        ctrlMode,
+	})
+}
 
-	commandValue :=
-		uint64(leftVerticalVal) |
-			leftHorizontalVal<<11 |
-			rightVerticalVal<<22 |
-			rightHorizontalVal<<33 |
-			leftCtlVal<<44 |
-			rightCtlVal<<45 |
-			ctrlMode<<46
+func (r *Robot) sendSticks(sticks virtualstick.Sticks) {
+	commandValue := virtualstick.DefaultCodec.Encode(sticks)
 
 	cc := service.DJICommandControllerInstance()
 
 	cc.DirectSendValue(dji.DJIMainControllerVirtualStick, int64(commandValue))
 }
-
-func lerp(a, b, t float32) float32 {
-	return a*(1-t) + b*t
-}
@@ -0,0 +1,140 @@
+package robot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster2/modules/robot/virtualstick"
+)
+
+const (
+	// Default rate at which the stick state is sent to the robot (50 Hz).
+	defaultSendInterval = 20 * time.Millisecond
+
+	// Default time without stick updates after which sticks are centered.
+	defaultDeadman = 500 * time.Millisecond
+)
+
+// SetSticks sets the virtual stick state. The state is continuously sent to
+// the robot in the background (see SetSendInterval) until Stop is called. If
+// SetSticks is not called again within the deadman timeout (see SetDeadman),
+// the sticks are centered. Input from gamepads can be shaped with a
+// virtualstick.Shaper before being set.
+func (r *Robot) SetSticks(sticks virtualstick.Sticks) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.sticks = sticks
+	r.lastUpdate = time.Now()
+
+	if r.senderQuit == nil {
+		// Each sender gets its own channels, so Stop only waits for the one
+		// it stopped even if SetSticks starts a new one meanwhile.
+		r.senderQuit = make(chan struct{})
+		r.senderDone = make(chan struct{})
+
+		go r.senderLoop(r.senderQuit, r.senderDone)
+	}
+}
+
+// Sticks returns the virtual stick state being sent to the robot. Sticks are
+// reported centered after the deadman timeout expires.
+func (r *Robot) Sticks() virtualstick.Sticks {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.currentSticksLocked(time.Now())
+}
+
+// SetSendInterval sets the interval between stick state updates sent to the
+// robot.
+func (r *Robot) SetSendInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("send interval must be positive")
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.sendInterval = interval
+
+	return nil
+}
+
+// SetDeadman sets for how long the sticks keep their state without calls to
+// SetSticks before they are centered. Zero disables the deadman.
+func (r *Robot) SetDeadman(timeout time.Duration) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.deadman = timeout
+}
+
+// CenterSticks centers the sticks without stopping the stick sender, so other
+// users of the robot can keep setting them. If the sender is not running, the
+// centered sticks are sent once.
+func (r *Robot) CenterSticks() {
+	r.m.Lock()
+	sticks := r.sticks.Centered()
+	r.sticks = sticks
+	running := r.senderQuit != nil
+	r.m.Unlock()
+
+	if !running {
+		r.sendSticks(sticks)
+	}
+}
+
+// Stop stops sending the stick state to the robot, making sure it saw the
+// sticks centered.
+func (r *Robot) Stop() {
+	r.m.Lock()
+	sticks := r.sticks.Centered()
+	r.sticks = sticks
+	senderQuit := r.senderQuit
+	senderDone := r.senderDone
+	r.senderQuit = nil
+	r.senderDone = nil
+	r.m.Unlock()
+
+	if senderQuit == nil {
+		return
+	}
+
+	close(senderQuit)
+	<-senderDone
+
+	r.sendSticks(sticks)
+}
+
+func (r *Robot) currentSticksLocked(now time.Time) virtualstick.Sticks {
+	if r.deadman > 0 && now.Sub(r.lastUpdate) > r.deadman {
+		return r.sticks.Centered()
+	}
+
+	return r.sticks
+}
+
+func (r *Robot) senderLoop(quitChan, doneChan chan struct{}) {
+	defer close(doneChan)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-quitChan:
+			return
+		}
+
+		r.m.Lock()
+		sticks := r.currentSticksLocked(time.Now())
+		interval := r.sendInterval
+		r.m.Unlock()
+
+		r.sendSticks(sticks)
+
+		timer.Reset(interval)
+	}
+}
@@ -0,0 +1,202 @@
+package robot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+	"github.com/brunoga/robomaster2/modules/robot/virtualstick"
+	"github.com/brunoga/robomaster2/support"
+)
+
+var testSticks = virtualstick.Sticks{
+	LeftVertical:   0.5,
+	LeftHorizontal: -0.25,
+	LeftTouched:    true,
+}
+
+func newTestRobot(t *testing.T) (*Robot, *servicetest.CommandController) {
+	cc := servicetest.Install(t)
+
+	r := NewRobot(support.NewLogger(nil, nil, nil, nil))
+	t.Cleanup(r.Stop)
+
+	err := r.SetSendInterval(5 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	return r, cc
+}
+
+// sentSticks returns the command values sent for the virtual sticks.
+func sentSticks(t *testing.T, cc *servicetest.CommandController) []int64 {
+	var values []int64
+	for _, send := range cc.DirectSends() {
+		if send.Key != dji.DJIMainControllerVirtualStick {
+			t.Fatalf("expected key %s, got %s",
+				dji.DJIMainControllerVirtualStick, send.Key)
+		}
+
+		values = append(values, send.Value)
+	}
+
+	return values
+}
+
+func encodeSticks(sticks virtualstick.Sticks) int64 {
+	return int64(virtualstick.DefaultCodec.Encode(sticks))
+}
+
+func TestRobot_SetSticks(t *testing.T) {
+	r, cc := newTestRobot(t)
+	r.SetDeadman(0)
+
+	r.SetSticks(testSticks)
+
+	if r.Sticks() != testSticks {
+		t.Fatalf("expected sticks %+v, got %+v", testSticks, r.Sticks())
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	r.Stop()
+
+	values := sentSticks(t, cc)
+	if len(values) < 3 {
+		t.Fatalf("expected sticks to be sent continuously, got %d sends",
+			len(values))
+	}
+
+	for i, value := range values[:len(values)-1] {
+		if value != encodeSticks(testSticks) {
+			t.Fatalf("expected send %d to be %d, got %d", i,
+				encodeSticks(testSticks), value)
+		}
+	}
+
+	// Stop makes sure the robot saw the sticks centered.
+	if values[len(values)-1] != encodeSticks(testSticks.Centered()) {
+		t.Fatalf("expected last send to be centered, got %d",
+			values[len(values)-1])
+	}
+
+	// Nothing else is sent after Stop.
+	time.Sleep(20 * time.Millisecond)
+
+	if len(sentSticks(t, cc)) != len(values) {
+		t.Fatalf("expected %d sends, got %d", len(values),
+			len(sentSticks(t, cc)))
+	}
+}
+
+func TestRobot_Deadman(t *testing.T) {
+	r, cc := newTestRobot(t)
+	r.SetDeadman(30 * time.Millisecond)
+
+	r.SetSticks(testSticks)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if r.Sticks() != testSticks.Centered() {
+		t.Fatalf("expected centered sticks, got %+v", r.Sticks())
+	}
+
+	values := sentSticks(t, cc)
+	if len(values) == 0 || values[0] != encodeSticks(testSticks) {
+		t.Fatalf("expected sticks to be sent first, got %v", values)
+	}
+	if values[len(values)-1] != encodeSticks(testSticks.Centered()) {
+		t.Fatalf("expected centered sticks to be sent after the deadman, "+
+			"got %d", values[len(values)-1])
+	}
+
+	// Setting the sticks again moves them.
+	r.SetSticks(testSticks)
+
+	if r.Sticks() != testSticks {
+		t.Fatalf("expected sticks %+v, got %+v", testSticks, r.Sticks())
+	}
+}
+
+func TestRobot_CenterSticks(t *testing.T) {
+	r, cc := newTestRobot(t)
+
+	// Not running. Centered sticks are sent once.
+	r.CenterSticks()
+
+	values := sentSticks(t, cc)
+	if len(values) != 1 || values[0] != encodeSticks(virtualstick.Sticks{}) {
+		t.Fatalf("expected one centered send, got %v", values)
+	}
+
+	// Running. The sender keeps going with centered sticks.
+	r.SetSticks(testSticks)
+	r.CenterSticks()
+
+	if r.Sticks() != testSticks.Centered() {
+		t.Fatalf("expected centered sticks, got %+v", r.Sticks())
+	}
+
+	count := len(sentSticks(t, cc))
+
+	time.Sleep(50 * time.Millisecond)
+
+	values = sentSticks(t, cc)
+	if len(values) <= count {
+		t.Fatalf("expected sender to keep running, got %d sends",
+			len(values))
+	}
+	if values[len(values)-1] != encodeSticks(testSticks.Centered()) {
+		t.Fatalf("expected centered sticks to be sent, got %d",
+			values[len(values)-1])
+	}
+}
+
+func TestRobot_SetSendInterval(t *testing.T) {
+	r := NewRobot(support.NewLogger(nil, nil, nil, nil))
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := r.SetSendInterval(interval)
+		if err == nil {
+			t.Errorf("%s: expected non-nil error, got nil", interval)
+		}
+	}
+}
+
+// Stop must not deadlock when SetSticks restarts the sender while it waits for
+// the previous one to finish.
+func TestRobot_Stop_ConcurrentSetSticks(t *testing.T) {
+	r, _ := newTestRobot(t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					r.SetSticks(testSticks)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					r.Stop()
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected Stop and SetSticks to finish, they deadlocked")
+	}
+}
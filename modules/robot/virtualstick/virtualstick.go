@@ -0,0 +1,188 @@
+// Package virtualstick encodes and decodes the virtual stick commands sent to
+// the robot and provides input shaping (deadzone, expo and inversion) for
+// stick values coming from gamepads or other input devices.
+//
+// A command packs each of the 4 axes as an 11 bit value around a center
+// (bits 0-10 left vertical, 11-21 left horizontal, 22-32 right vertical and
+// 33-43 right horizontal), followed by the left and right touch flags (bits 44
+// and 45) and the control mode (bits 46 and up).
+package virtualstick
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	axisBits = 11
+	axisMask = 1<<axisBits - 1
+
+	leftTouchedBit  = 44
+	rightTouchedBit = 45
+	ctrlModeShift   = 46
+
+	// Maximum control mode value that fits in a command.
+	MaxCtrlMode = 1<<(64-ctrlModeShift) - 1
+)
+
+var (
+	// DefaultCodec is the codec used by the S1 and EP robots.
+	DefaultCodec = Codec{
+		Center: 1024,
+		Range:  660,
+	}
+)
+
+// Sticks is the state of the virtual sticks. Axes are in the [-1, 1] range.
+// The touch flags tell the robot that the corresponding stick is being
+// actively controlled and CtrlMode holds the control mode bits sent together
+// with the stick positions.
+type Sticks struct {
+	LeftVertical    float32
+	LeftHorizontal  float32
+	RightVertical   float32
+	RightHorizontal float32
+	LeftTouched     bool
+	RightTouched    bool
+	CtrlMode        uint64
+}
+
+func (s Sticks) String() string {
+	return fmt.Sprintf("Left:(%.2f, %.2f, %t), Right:(%.2f, %.2f, %t), "+
+		"CtrlMode:%d", s.LeftVertical, s.LeftHorizontal, s.LeftTouched,
+		s.RightVertical, s.RightHorizontal, s.RightTouched, s.CtrlMode)
+}
+
+// Centered returns the sticks centered and not touched, keeping the control
+// mode.
+func (s Sticks) Centered() Sticks {
+	return Sticks{
+		CtrlMode: s.CtrlMode,
+	}
+}
+
+// Codec converts between Sticks and the command values sent to the robot.
+// Axis values are encoded as Center + axis * Range.
+type Codec struct {
+	Center uint64
+	Range  uint64
+}
+
+// Encode returns the command value for the given sticks. Axes are clamped to
+// the [-1, 1] range and the control mode is truncated to MaxCtrlMode.
+func (c Codec) Encode(sticks Sticks) uint64 {
+	value := c.encodeAxis(sticks.LeftVertical) |
+		c.encodeAxis(sticks.LeftHorizontal)<<axisBits |
+		c.encodeAxis(sticks.RightVertical)<<(2*axisBits) |
+		c.encodeAxis(sticks.RightHorizontal)<<(3*axisBits) |
+		(sticks.CtrlMode&MaxCtrlMode)<<ctrlModeShift
+
+	if sticks.LeftTouched {
+		value |= 1 << leftTouchedBit
+	}
+	if sticks.RightTouched {
+		value |= 1 << rightTouchedBit
+	}
+
+	return value
+}
+
+// Decode returns the sticks encoded in the given command value.
+func (c Codec) Decode(value uint64) Sticks {
+	return Sticks{
+		LeftVertical:    c.decodeAxis(value),
+		LeftHorizontal:  c.decodeAxis(value >> axisBits),
+		RightVertical:   c.decodeAxis(value >> (2 * axisBits)),
+		RightHorizontal: c.decodeAxis(value >> (3 * axisBits)),
+		LeftTouched:     value&(1<<leftTouchedBit) != 0,
+		RightTouched:    value&(1<<rightTouchedBit) != 0,
+		CtrlMode:        value >> ctrlModeShift,
+	}
+}
+
+func (c Codec) encodeAxis(axis float32) uint64 {
+	axis = clamp(axis)
+
+	return uint64(math.Round(float64(c.Center)+
+		float64(axis)*float64(c.Range))) & axisMask
+}
+
+func (c Codec) decodeAxis(value uint64) float32 {
+	return float32((float64(value&axisMask) - float64(c.Center)) /
+		float64(c.Range))
+}
+
+// AxisShaping describes how raw input for an axis is shaped.
+type AxisShaping struct {
+	// Inputs with an absolute value below Deadzone (in the [0, 1) range) are
+	// reported as 0. The remaining range is scaled so the output is still
+	// continuous.
+	Deadzone float32
+
+	// Expo (in the [0, 1] range) blends a linear response (0) with a cubic
+	// one (1), giving finer control around the center.
+	Expo float32
+
+	// Inverted flips the axis direction.
+	Inverted bool
+}
+
+// Apply returns the shaped value for the given raw axis input.
+func (a AxisShaping) Apply(axis float32) float32 {
+	axis = clamp(axis)
+
+	if a.Inverted {
+		axis = -axis
+	}
+
+	magnitude := float32(math.Abs(float64(axis)))
+	if magnitude <= a.Deadzone {
+		return 0
+	}
+
+	if a.Deadzone > 0 && a.Deadzone < 1 {
+		magnitude = (magnitude - a.Deadzone) / (1 - a.Deadzone)
+	}
+
+	magnitude = (1-a.Expo)*magnitude + a.Expo*magnitude*magnitude*magnitude
+
+	if axis < 0 {
+		return -magnitude
+	}
+
+	return magnitude
+}
+
+// Shaper shapes raw stick input before it is sent to the robot.
+type Shaper struct {
+	LeftVertical    AxisShaping
+	LeftHorizontal  AxisShaping
+	RightVertical   AxisShaping
+	RightHorizontal AxisShaping
+}
+
+// Apply returns the given sticks with all axes shaped. Touch flags and
+// control mode are kept.
+func (s Shaper) Apply(sticks Sticks) Sticks {
+	sticks.LeftVertical = s.LeftVertical.Apply(sticks.LeftVertical)
+	sticks.LeftHorizontal = s.LeftHorizontal.Apply(sticks.LeftHorizontal)
+	sticks.RightVertical = s.RightVertical.Apply(sticks.RightVertical)
+	sticks.RightHorizontal = s.RightHorizontal.Apply(sticks.RightHorizontal)
+
+	return sticks
+}
+
+func clamp(axis float32) float32 {
+	if axis != axis {
+		// NaN.
+		return 0
+	}
+	if axis < -1 {
+		return -1
+	}
+	if axis > 1 {
+		return 1
+	}
+
+	return axis
+}
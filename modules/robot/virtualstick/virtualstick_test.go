@@ -0,0 +1,147 @@
+package virtualstick
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCodec_EncodeCentered(t *testing.T) {
+	value := DefaultCodec.Encode(Sticks{})
+
+	expected := uint64(1024 | 1024<<11 | 1024<<22 | 1024<<33)
+	if value != expected {
+		t.Fatalf("expected %#x, got %#x", expected, value)
+	}
+}
+
+func TestCodec_EncodeFields(t *testing.T) {
+	value := DefaultCodec.Encode(Sticks{
+		LeftVertical:    1,
+		LeftHorizontal:  -1,
+		RightVertical:   0.5,
+		RightHorizontal: -0.5,
+		LeftTouched:     true,
+		RightTouched:    true,
+		CtrlMode:        3,
+	})
+
+	expected := uint64(1684 | 364<<11 | 1354<<22 | 694<<33 | 1<<44 | 1<<45 |
+		3<<46)
+	if value != expected {
+		t.Fatalf("expected %#x, got %#x", expected, value)
+	}
+}
+
+func TestCodec_EncodeClamps(t *testing.T) {
+	value := DefaultCodec.Encode(Sticks{
+		LeftVertical:    2,
+		RightHorizontal: -3,
+		RightVertical:   float32(math.NaN()),
+	})
+
+	expected := DefaultCodec.Encode(Sticks{
+		LeftVertical:    1,
+		RightHorizontal: -1,
+	})
+	if value != expected {
+		t.Fatalf("expected %#x, got %#x", expected, value)
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	for _, sticks := range []Sticks{
+		{},
+		{LeftVertical: 1, LeftHorizontal: -1, RightVertical: 1,
+			RightHorizontal: -1},
+		{LeftVertical: 0.25, LeftTouched: true},
+		{RightHorizontal: -0.75, RightTouched: true, CtrlMode: 1},
+		{LeftHorizontal: 0.1, RightVertical: -0.3, CtrlMode: MaxCtrlMode},
+	} {
+		value := DefaultCodec.Encode(sticks)
+		decoded := DefaultCodec.Decode(value)
+
+		if !sticksAlmostEqual(decoded, sticks) {
+			t.Fatalf("expected %s, got %s", sticks, decoded)
+		}
+
+		if DefaultCodec.Encode(decoded) != value {
+			t.Fatalf("expected %#x, got %#x", value,
+				DefaultCodec.Encode(decoded))
+		}
+	}
+}
+
+func TestAxisShaping_Deadzone(t *testing.T) {
+	shaping := AxisShaping{Deadzone: 0.1}
+
+	if value := shaping.Apply(0.05); value != 0 {
+		t.Fatalf("expected 0, got %f", value)
+	}
+	if value := shaping.Apply(-0.1); value != 0 {
+		t.Fatalf("expected 0, got %f", value)
+	}
+	if value := shaping.Apply(1); value != 1 {
+		t.Fatalf("expected 1, got %f", value)
+	}
+	if value := shaping.Apply(0.55); !almostEqual(value, 0.5) {
+		t.Fatalf("expected 0.5, got %f", value)
+	}
+	if value := shaping.Apply(-0.55); !almostEqual(value, -0.5) {
+		t.Fatalf("expected -0.5, got %f", value)
+	}
+}
+
+func TestAxisShaping_Expo(t *testing.T) {
+	shaping := AxisShaping{Expo: 1}
+
+	if value := shaping.Apply(0.5); !almostEqual(value, 0.125) {
+		t.Fatalf("expected 0.125, got %f", value)
+	}
+	if value := shaping.Apply(-1); value != -1 {
+		t.Fatalf("expected -1, got %f", value)
+	}
+
+	shaping.Expo = 0.5
+	if value := shaping.Apply(0.5); !almostEqual(value, 0.3125) {
+		t.Fatalf("expected 0.3125, got %f", value)
+	}
+}
+
+func TestShaper_Apply(t *testing.T) {
+	shaper := Shaper{
+		LeftVertical:    AxisShaping{Inverted: true},
+		RightHorizontal: AxisShaping{Deadzone: 0.2},
+	}
+
+	sticks := shaper.Apply(Sticks{
+		LeftVertical:    0.5,
+		LeftHorizontal:  0.5,
+		RightHorizontal: 0.1,
+		LeftTouched:     true,
+		CtrlMode:        2,
+	})
+
+	expected := Sticks{
+		LeftVertical:   -0.5,
+		LeftHorizontal: 0.5,
+		LeftTouched:    true,
+		CtrlMode:       2,
+	}
+	if sticks != expected {
+		t.Fatalf("expected %s, got %s", expected, sticks)
+	}
+}
+
+func sticksAlmostEqual(a, b Sticks) bool {
+	return almostEqual(a.LeftVertical, b.LeftVertical) &&
+		almostEqual(a.LeftHorizontal, b.LeftHorizontal) &&
+		almostEqual(a.RightVertical, b.RightVertical) &&
+		almostEqual(a.RightHorizontal, b.RightHorizontal) &&
+		a.LeftTouched == b.LeftTouched && a.RightTouched == b.RightTouched &&
+		a.CtrlMode == b.CtrlMode
+}
+
+// almostEqual returns true if a and b are within half an encoding step.
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 0.5/660
+}
@@ -0,0 +1,363 @@
+package video
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueueSize = 4
+
+	// Interval over which handler frame rates are measured.
+	fpsWindow = time.Second
+)
+
+// DeliveryPolicy is how frames are queued for a handler that is not keeping
+// up with the video frame rate.
+type DeliveryPolicy int
+
+const (
+	// Only the most recent frame is queued. Older queued frames are dropped.
+	DeliveryLatest DeliveryPolicy = iota
+
+	// Frames are queued in order up to the queue size. New frames are dropped
+	// while the queue is full.
+	DeliveryFIFO
+
+	// Only every Nth frame is delivered. Otherwise behaves like
+	// DeliveryLatest.
+	DeliveryEveryNth
+)
+
+func (d DeliveryPolicy) String() string {
+	switch d {
+	case DeliveryLatest:
+		return "Latest"
+	case DeliveryFIFO:
+		return "FIFO"
+	case DeliveryEveryNth:
+		return "EveryNth"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int(d))
+}
+
+// HandlerOptions controls how frames are delivered to a handler.
+type HandlerOptions struct {
+	Policy DeliveryPolicy
+
+	// Maximum number of queued frames for DeliveryFIFO. Zero means a default
+	// size.
+	QueueSize int
+
+	// Deliver one of every N frames for DeliveryEveryNth.
+	N int
+
+	// Maximum frames per second delivered to the handler. Zero means no
+	// limit, unless the handler implements FrameRateLimiter.
+	MaxFrameRate float64
+}
+
+// DefaultHandlerOptions are the options used by AddVideoHandler.
+var DefaultHandlerOptions = HandlerOptions{
+	Policy: DeliveryLatest,
+}
+
+// Validate checks if the options are valid. Returns a nil error if they are
+// and a non-nil error otherwise.
+func (o HandlerOptions) Validate() error {
+	switch o.Policy {
+	case DeliveryLatest, DeliveryFIFO:
+	case DeliveryEveryNth:
+		if o.N < 1 {
+			return fmt.Errorf("invalid N for %s policy: %d", o.Policy, o.N)
+		}
+	default:
+		return fmt.Errorf("invalid delivery policy: %s", o.Policy)
+	}
+
+	if o.QueueSize < 0 {
+		return fmt.Errorf("invalid queue size: %d", o.QueueSize)
+	}
+
+	if o.MaxFrameRate < 0 {
+		return fmt.Errorf("invalid max frame rate: %f", o.MaxFrameRate)
+	}
+
+	return nil
+}
+
+// HandlerStats are frame delivery statistics for a handler.
+type HandlerStats struct {
+	// Frames received while the handler was registered.
+	Received uint64
+
+	// Frames passed to the handler.
+	Delivered uint64
+
+	// Frames skipped due to the delivery policy or frame rate limit.
+	Skipped uint64
+
+	// Frames dropped because the handler was not keeping up.
+	Dropped uint64
+
+	// Frames per second recently delivered to the handler.
+	FPS float64
+}
+
+// handlerRunner delivers frames to a handler in its own goroutine, so slow
+// handlers do not delay other handlers or video reception.
+type handlerRunner struct {
+	handler     Handler
+	options     HandlerOptions
+	minInterval time.Duration
+
+	m            sync.Mutex
+	queue        []queueItem
+	stats        HandlerStats
+	lastAccepted time.Time
+	windowStart  time.Time
+	windowCount  int
+	stopped      bool
+
+	notifyChan chan struct{}
+	quitChan   chan struct{}
+}
+
+// queueItem is either a frame or a resolution change.
+type queueItem struct {
+	frame       *Frame
+	textureInfo *TextureInfo
+}
+
+func newHandlerRunner(handler Handler, options HandlerOptions) *handlerRunner {
+	maxFrameRate := options.MaxFrameRate
+	if limiter, ok := handler.(FrameRateLimiter); ok && maxFrameRate == 0 {
+		maxFrameRate = limiter.MaxFrameRate()
+	}
+
+	var minInterval time.Duration
+	if maxFrameRate > 0 {
+		minInterval = time.Duration(float64(time.Second) / maxFrameRate)
+	}
+
+	if options.QueueSize == 0 {
+		options.QueueSize = defaultQueueSize
+	}
+
+	h := &handlerRunner{
+		handler:     handler,
+		options:     options,
+		minInterval: minInterval,
+		notifyChan:  make(chan struct{}, 1),
+		quitChan:    make(chan struct{}),
+	}
+
+	go h.loop()
+
+	return h
+}
+
+// enqueueFrame queues the given frame for delivery according to the handler
+// options. Never blocks.
+func (h *handlerRunner) enqueueFrame(frame *Frame) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.stopped {
+		return
+	}
+
+	h.stats.Received++
+
+	if h.options.Policy == DeliveryEveryNth &&
+		h.stats.Received%uint64(h.options.N) != 0 {
+		h.stats.Skipped++
+		return
+	}
+
+	// Allow frames arriving slightly early so rate limits matching the video
+	// frame rate do not skip frames due to jitter.
+	if h.minInterval > 0 && !h.lastAccepted.IsZero() &&
+		frame.Time.Sub(h.lastAccepted) < h.minInterval*9/10 {
+		h.stats.Skipped++
+		return
+	}
+
+	if h.options.Policy == DeliveryFIFO {
+		if h.queuedFramesLocked() >= h.options.QueueSize {
+			h.stats.Dropped++
+			return
+		}
+	} else {
+		h.dropQueuedFramesLocked()
+	}
+
+	h.lastAccepted = frame.Time
+	h.queue = append(h.queue, queueItem{frame: frame.Retain()})
+
+	h.notify()
+}
+
+// enqueueTextureInfo queues a resolution change notification. These are never
+// dropped.
+func (h *handlerRunner) enqueueTextureInfo(textureInfo TextureInfo) {
+	if _, ok := h.handler.(ResolutionHandler); !ok {
+		return
+	}
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.stopped {
+		return
+	}
+
+	h.queue = append(h.queue, queueItem{textureInfo: &textureInfo})
+
+	h.notify()
+}
+
+func (h *handlerRunner) Stats() HandlerStats {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	stats := h.stats
+
+	// Report no frame rate if nothing was delivered for a while.
+	if time.Since(h.windowStart) > 2*fpsWindow {
+		stats.FPS = 0
+	}
+
+	return stats
+}
+
+// stop stops delivering frames. Frames still queued are released. Does not wait
+// for a frame being delivered, so it can be called from the handler itself.
+func (h *handlerRunner) stop() {
+	h.m.Lock()
+	h.stopped = true
+	h.m.Unlock()
+
+	close(h.quitChan)
+}
+
+func (h *handlerRunner) notify() {
+	select {
+	case h.notifyChan <- struct{}{}:
+	default:
+	}
+}
+
+func (h *handlerRunner) loop() {
+	defer h.releaseQueue()
+
+	for {
+		select {
+		case <-h.notifyChan:
+		case <-h.quitChan:
+			return
+		}
+
+		for {
+			item, ok := h.dequeue()
+			if !ok {
+				break
+			}
+
+			select {
+			case <-h.quitChan:
+				if item.frame != nil {
+					item.frame.Release()
+				}
+				return
+			default:
+			}
+
+			h.deliver(item)
+		}
+	}
+}
+
+func (h *handlerRunner) dequeue() (queueItem, bool) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if len(h.queue) == 0 {
+		return queueItem{}, false
+	}
+
+	item := h.queue[0]
+	h.queue[0] = queueItem{}
+	h.queue = h.queue[1:]
+
+	return item, true
+}
+
+func (h *handlerRunner) deliver(item queueItem) {
+	if item.textureInfo != nil {
+		h.handler.(ResolutionHandler).HandleResolution(*item.textureInfo)
+		return
+	}
+
+	h.handler.HandleVideo(item.frame)
+	item.frame.Release()
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.stats.Delivered++
+
+	now := time.Now()
+	if h.windowStart.IsZero() {
+		h.windowStart = now
+	}
+
+	h.windowCount++
+
+	if elapsed := now.Sub(h.windowStart); elapsed >= fpsWindow {
+		h.stats.FPS = float64(h.windowCount) / elapsed.Seconds()
+		h.windowStart = now
+		h.windowCount = 0
+	}
+}
+
+func (h *handlerRunner) queuedFramesLocked() int {
+	n := 0
+	for _, item := range h.queue {
+		if item.frame != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (h *handlerRunner) dropQueuedFramesLocked() {
+	queue := h.queue[:0]
+	for _, item := range h.queue {
+		if item.frame != nil {
+			item.frame.Release()
+			h.stats.Dropped++
+			continue
+		}
+
+		queue = append(queue, item)
+	}
+
+	h.queue = queue
+}
+
+func (h *handlerRunner) releaseQueue() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for _, item := range h.queue {
+		if item.frame != nil {
+			item.frame.Release()
+		}
+	}
+
+	h.queue = nil
+}
@@ -0,0 +1,307 @@
+package video
+
+import (
+	"image"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testHandler records the sequence numbers of delivered frames. If block is
+// not nil, deliveries wait until it is closed.
+type testHandler struct {
+	block   chan struct{}
+	started chan uint64
+
+	m    sync.Mutex
+	seqs []uint64
+}
+
+func newTestHandler(block bool) *testHandler {
+	h := &testHandler{
+		started: make(chan uint64, 100),
+	}
+
+	if block {
+		h.block = make(chan struct{})
+	}
+
+	return h
+}
+
+func (h *testHandler) HandleVideo(frame *Frame) {
+	h.started <- frame.Seq
+
+	if h.block != nil {
+		<-h.block
+	}
+
+	h.m.Lock()
+	h.seqs = append(h.seqs, frame.Seq)
+	h.m.Unlock()
+}
+
+func (h *testHandler) delivered() []uint64 {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return append([]uint64(nil), h.seqs...)
+}
+
+type limitedHandler struct {
+	testHandler
+}
+
+func (l *limitedHandler) MaxFrameRate() float64 {
+	return 10
+}
+
+// enqueueFrames enqueues frames with the given sequence numbers, spaced
+// interval apart, releasing the caller reference to them afterwards.
+func enqueueFrames(runner *handlerRunner, pool *framePool, start time.Time,
+	interval time.Duration, seqs ...uint64) {
+	for _, seq := range seqs {
+		frame := pool.get()
+		frame.Seq = seq
+		frame.Time = start.Add(time.Duration(seq) * interval)
+
+		runner.enqueueFrame(frame)
+
+		frame.Release()
+	}
+}
+
+func waitForStarted(t *testing.T, h *testHandler, seq uint64) {
+	t.Helper()
+
+	select {
+	case got := <-h.started:
+		if got != seq {
+			t.Fatalf("expected frame %d to be delivered, got %d", seq, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for frame %d", seq)
+	}
+}
+
+func waitForDelivered(t *testing.T, runner *handlerRunner, delivered uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runner.Stats().Delivered != delivered {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d delivered frames, got %+v", delivered,
+				runner.Stats())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func checkStats(t *testing.T, runner *handlerRunner, expected HandlerStats) {
+	t.Helper()
+
+	stats := runner.Stats()
+	stats.FPS = 0
+
+	if stats != expected {
+		t.Fatalf("expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestHandlerOptions_Validate(t *testing.T) {
+	tests := []struct {
+		options HandlerOptions
+		valid   bool
+	}{
+		{DefaultHandlerOptions, true},
+		{HandlerOptions{Policy: DeliveryFIFO, QueueSize: 10}, true},
+		{HandlerOptions{Policy: DeliveryEveryNth, N: 3}, true},
+		{HandlerOptions{Policy: DeliveryEveryNth}, false},
+		{HandlerOptions{Policy: 3}, false},
+		{HandlerOptions{QueueSize: -1}, false},
+		{HandlerOptions{MaxFrameRate: -1}, false},
+	}
+
+	for _, test := range tests {
+		err := test.options.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("%+v: expected valid %t, got error %v", test.options,
+				test.valid, err)
+		}
+	}
+}
+
+func TestHandlerRunner_Latest(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+	h := newTestHandler(true)
+	runner := newHandlerRunner(h, DefaultHandlerOptions)
+	defer runner.stop()
+
+	start := time.Now()
+
+	enqueueFrames(runner, pool, start, 0, 1)
+	waitForStarted(t, h, 1)
+
+	// Only the most recent frame is kept while the handler is busy.
+	enqueueFrames(runner, pool, start, 0, 2, 3, 4)
+
+	close(h.block)
+
+	waitForStarted(t, h, 4)
+	waitForDelivered(t, runner, 2)
+
+	if got := h.delivered(); !reflect.DeepEqual(got, []uint64{1, 4}) {
+		t.Fatalf("expected frames [1 4], got %v", got)
+	}
+
+	checkStats(t, runner, HandlerStats{Received: 4, Delivered: 2,
+		Dropped: 2})
+}
+
+func TestHandlerRunner_FIFO(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+	h := newTestHandler(true)
+	runner := newHandlerRunner(h, HandlerOptions{
+		Policy:    DeliveryFIFO,
+		QueueSize: 2,
+	})
+	defer runner.stop()
+
+	start := time.Now()
+
+	enqueueFrames(runner, pool, start, 0, 1)
+	waitForStarted(t, h, 1)
+
+	// Frames are queued in order until the queue is full.
+	enqueueFrames(runner, pool, start, 0, 2, 3, 4)
+
+	close(h.block)
+
+	waitForDelivered(t, runner, 3)
+
+	if got := h.delivered(); !reflect.DeepEqual(got, []uint64{1, 2, 3}) {
+		t.Fatalf("expected frames [1 2 3], got %v", got)
+	}
+
+	checkStats(t, runner, HandlerStats{Received: 4, Delivered: 3,
+		Dropped: 1})
+}
+
+func TestHandlerRunner_EveryNth(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+	h := newTestHandler(false)
+	runner := newHandlerRunner(h, HandlerOptions{
+		Policy: DeliveryEveryNth,
+		N:      3,
+	})
+	defer runner.stop()
+
+	start := time.Now()
+
+	for seq := uint64(1); seq <= 9; seq++ {
+		enqueueFrames(runner, pool, start, 0, seq)
+		waitForDelivered(t, runner, seq/3)
+	}
+
+	if got := h.delivered(); !reflect.DeepEqual(got, []uint64{3, 6, 9}) {
+		t.Fatalf("expected frames [3 6 9], got %v", got)
+	}
+
+	checkStats(t, runner, HandlerStats{Received: 9, Delivered: 3,
+		Skipped: 6})
+}
+
+func TestHandlerRunner_MaxFrameRate(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+	h := newTestHandler(false)
+	runner := newHandlerRunner(h, HandlerOptions{
+		MaxFrameRate: 10,
+	})
+	defer runner.stop()
+
+	start := time.Now()
+
+	// Frames are 40ms apart, so only one in every 3 is within the limit.
+	for seq := uint64(0); seq < 9; seq++ {
+		enqueueFrames(runner, pool, start, 40*time.Millisecond, seq)
+		waitForDelivered(t, runner, seq/3+1)
+	}
+
+	if got := h.delivered(); !reflect.DeepEqual(got, []uint64{0, 3, 6}) {
+		t.Fatalf("expected frames [0 3 6], got %v", got)
+	}
+
+	checkStats(t, runner, HandlerStats{Received: 9, Delivered: 3,
+		Skipped: 6})
+}
+
+func TestHandlerRunner_FrameRateLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  HandlerOptions
+		expected time.Duration
+	}{
+		{"Handler", DefaultHandlerOptions, 100 * time.Millisecond},
+		{"Options", HandlerOptions{MaxFrameRate: 20}, 50 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner := newHandlerRunner(&limitedHandler{}, test.options)
+			defer runner.stop()
+
+			if runner.minInterval != test.expected {
+				t.Fatalf("expected minimum interval %s, got %s",
+					test.expected, runner.minInterval)
+			}
+		})
+	}
+}
+
+func TestHandlerRunner_Stop(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+	h := newTestHandler(true)
+	runner := newHandlerRunner(h, HandlerOptions{
+		Policy: DeliveryFIFO,
+	})
+
+	start := time.Now()
+
+	enqueueFrames(runner, pool, start, 0, 1)
+	waitForStarted(t, h, 1)
+
+	enqueueFrames(runner, pool, start, 0, 2, 3)
+
+	runner.stop()
+
+	// Frames are ignored after stopping.
+	enqueueFrames(runner, pool, start, 0, 4)
+
+	close(h.block)
+
+	// All frames are eventually released back to the pool.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		pool.m.Lock()
+		free := len(pool.free)
+		pool.m.Unlock()
+
+		if free == 4 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 4 pooled frames, got %d", free)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := h.delivered(); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Fatalf("expected frames [1], got %v", got)
+	}
+
+	checkStats(t, runner, HandlerStats{Received: 3, Delivered: 1})
+}
@@ -0,0 +1,118 @@
+package video
+
+import (
+	"image"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Maximum number of released frames kept around for reuse.
+	maxPooledFrames = 8
+)
+
+// Frame is a video frame with explicit ownership. Frames are reference
+// counted and their buffers are reused once the last reference is released.
+// A Handler owns a reference only for the duration of HandleVideo. To keep a
+// frame after that, call Retain and then Release when done with it. A frame
+// must not be used after its last reference is released.
+type Frame struct {
+	*RGB
+
+	// Sequence number of the frame. Incremented for every frame received
+	// (including dropped ones), so gaps indicate dropped frames.
+	Seq uint64
+
+	// Time the frame was received.
+	Time time.Time
+
+	refs atomic.Int32
+	pool *framePool
+}
+
+// Retain adds a reference to the frame and returns it.
+func (f *Frame) Retain() *Frame {
+	if f.refs.Add(1) <= 1 {
+		panic("video: Retain called on released frame")
+	}
+
+	return f
+}
+
+// Release releases a reference to the frame. When the last reference is
+// released, the frame buffer is returned to the pool it came from.
+func (f *Frame) Release() {
+	refs := f.refs.Add(-1)
+	if refs < 0 {
+		panic("video: Release called on released frame")
+	}
+
+	if refs == 0 && f.pool != nil {
+		f.pool.put(f)
+	}
+}
+
+// Clone returns a copy of the frame that is not pooled and does not need to be
+// released.
+func (f *Frame) Clone() *Frame {
+	clone := &Frame{
+		RGB: &RGB{
+			Pix:    append([]uint8(nil), f.Pix...),
+			Stride: f.Stride,
+			Rect:   f.Rect,
+		},
+		Seq:  f.Seq,
+		Time: f.Time,
+	}
+	clone.refs.Store(1)
+
+	return clone
+}
+
+// framePool is a pool of frames with the same bounds.
+type framePool struct {
+	m    sync.Mutex
+	rect image.Rectangle
+	free []*Frame
+}
+
+func newFramePool(rect image.Rectangle) *framePool {
+	return &framePool{
+		rect: rect,
+	}
+}
+
+// get returns a frame with a single reference, reusing a released one if
+// possible.
+func (p *framePool) get() *Frame {
+	p.m.Lock()
+
+	var f *Frame
+	if n := len(p.free); n > 0 {
+		f = p.free[n-1]
+		p.free = p.free[:n-1]
+	}
+
+	p.m.Unlock()
+
+	if f == nil {
+		f = &Frame{
+			RGB:  NewRGB(p.rect),
+			pool: p,
+		}
+	}
+
+	f.refs.Store(1)
+
+	return f
+}
+
+func (p *framePool) put(f *Frame) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if len(p.free) < maxPooledFrames {
+		p.free = append(p.free, f)
+	}
+}
@@ -0,0 +1,114 @@
+package video
+
+import (
+	"image"
+	"testing"
+)
+
+func expectPanic(t *testing.T, f func()) {
+	t.Helper()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	f()
+}
+
+func TestFrame_RetainRelease(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+
+	frame := pool.get()
+	if frame.Retain() != frame {
+		t.Fatalf("expected Retain to return the frame")
+	}
+
+	frame.Release()
+	if len(pool.free) != 0 {
+		t.Fatalf("expected frame not to be pooled while retained")
+	}
+
+	frame.Release()
+	if len(pool.free) != 1 {
+		t.Fatalf("expected frame to be pooled, got %d pooled frames",
+			len(pool.free))
+	}
+}
+
+func TestFrame_DoubleRelease(t *testing.T) {
+	frame := newFramePool(image.Rect(0, 0, 4, 2)).get()
+
+	frame.Release()
+
+	expectPanic(t, frame.Release)
+}
+
+func TestFrame_RetainReleased(t *testing.T) {
+	frame := newFramePool(image.Rect(0, 0, 4, 2)).get()
+
+	frame.Release()
+
+	expectPanic(t, func() { frame.Retain() })
+}
+
+func TestFrame_Clone(t *testing.T) {
+	frame := newFramePool(image.Rect(0, 0, 4, 2)).get()
+	frame.Seq = 42
+	frame.Pix[0] = 1
+
+	clone := frame.Clone()
+	frame.Release()
+
+	if clone.Seq != 42 || clone.Rect != frame.Rect {
+		t.Fatalf("expected clone to match frame, got %+v", clone)
+	}
+
+	frame.Pix[0] = 2
+	if clone.Pix[0] != 1 {
+		t.Fatalf("expected clone pixels to be a copy")
+	}
+
+	// Clones are not pooled, so releasing them is harmless.
+	clone.Release()
+}
+
+func TestFramePool_Reuse(t *testing.T) {
+	rect := image.Rect(0, 0, 4, 2)
+	pool := newFramePool(rect)
+
+	frame := pool.get()
+	frame.Release()
+
+	reused := pool.get()
+	if reused != frame {
+		t.Fatalf("expected released frame to be reused")
+	}
+
+	if reused.Rect != rect {
+		t.Fatalf("expected bounds %v, got %v", rect, reused.Rect)
+	}
+
+	// A reused frame starts with a single reference.
+	reused.Release()
+	expectPanic(t, reused.Release)
+}
+
+func TestFramePool_Limit(t *testing.T) {
+	pool := newFramePool(image.Rect(0, 0, 4, 2))
+
+	frames := make([]*Frame, maxPooledFrames+2)
+	for i := range frames {
+		frames[i] = pool.get()
+	}
+
+	for _, frame := range frames {
+		frame.Release()
+	}
+
+	if len(pool.free) != maxPooledFrames {
+		t.Fatalf("expected %d pooled frames, got %d", maxPooledFrames,
+			len(pool.free))
+	}
+}
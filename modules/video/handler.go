@@ -1,5 +1,24 @@
 package video
 
+// Handler handles video frames. Each handler is called from its own goroutine,
+// so a slow handler does not delay others (see HandlerOptions for what
+// happens to frames it can not keep up with). The frame is only guaranteed to
+// be valid until HandleVideo returns (see Frame for how to keep it for
+// longer).
 type Handler interface {
-	HandleVideo(img *RGB)
+	HandleVideo(frame *Frame)
+}
+
+// ResolutionHandler can be implemented by a Handler that wants to be notified
+// when the resolution of video frames changes. HandleResolution is called
+// before the first frame with the new resolution is handled.
+type ResolutionHandler interface {
+	HandleResolution(info TextureInfo)
+}
+
+// FrameRateLimiter can be implemented by a Handler that wants to receive at
+// most MaxFrameRate frames per second. Ignored if HandlerOptions.MaxFrameRate
+// is set.
+type FrameRateLimiter interface {
+	MaxFrameRate() float64
 }
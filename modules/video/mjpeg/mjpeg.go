@@ -0,0 +1,278 @@
+// Package mjpeg provides an http.Handler that streams live video as MJPEG
+// (multipart/x-mixed-replace JPEG frames), which browsers can display directly
+// in an <img> element.
+package mjpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/brunoga/robomaster2/internal/stream"
+	"github.com/brunoga/robomaster2/modules/video"
+)
+
+// Source is a source of video frames. It is implemented by *video.Video.
+type Source interface {
+	AddVideoHandler(videoHandler video.Handler) (int, error)
+	RemoveVideoHandler(id int) error
+}
+
+// Options controls how video is encoded.
+type Options struct {
+	// JPEG quality, from 1 to 100.
+	Quality int
+
+	// Maximum frames per second streamed. Zero means no limit.
+	MaxFrameRate float64
+
+	// Factor frames are scaled by, greater than 0 and up to 1.
+	Scale float64
+}
+
+// DefaultOptions are reasonable options for viewing video over a local
+// network.
+var DefaultOptions = Options{
+	Quality:      75,
+	MaxFrameRate: 15,
+	Scale:        1,
+}
+
+// Validate checks if the options are valid. Returns a nil error if they are
+// and a non-nil error otherwise.
+func (o Options) Validate() error {
+	if o.Quality < 1 || o.Quality > 100 {
+		return fmt.Errorf("invalid JPEG quality: %d", o.Quality)
+	}
+
+	if o.MaxFrameRate < 0 {
+		return fmt.Errorf("invalid max frame rate: %f", o.MaxFrameRate)
+	}
+
+	if o.Scale <= 0 || o.Scale > 1 {
+		return fmt.Errorf("invalid scale: %f", o.Scale)
+	}
+
+	return nil
+}
+
+// Server is an http.Handler streaming video from a Source to any number of
+// viewers. All viewers share a single encoder, which is only registered as a
+// video handler while at least one viewer is connected.
+type Server struct {
+	source  Source
+	options Options
+	jpegs   *stream.Stream[[]byte]
+
+	m         sync.Mutex
+	viewers   int
+	handlerID int
+}
+
+// New returns a new Server instance that streams video from the given source
+// encoded with the given options. Returns the Server instance and a nil error
+// on success and nil and a non-nil error on failure.
+func New(source Source, options Options) (*Server, error) {
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		source:  source,
+		options: options,
+		jpegs:   stream.New[[]byte](),
+	}, nil
+}
+
+// Viewers returns the number of currently connected viewers.
+func (s *Server) Viewers() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.viewers
+}
+
+// ServeHTTP streams video to the client until it disconnects.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before connecting so the first frame is not missed.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	jpegChan := s.jpegs.Subscribe(ctx)
+
+	err := s.addViewer()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.removeViewer()
+
+	mw := multipart.NewWriter(w)
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+
+		mw.Boundary())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Start with the current frame, if any, so new viewers see something
+	// right away.
+	var latest []byte
+	if data, ok := s.jpegs.Latest(); ok {
+		if writePart(mw, data) != nil {
+			return
+		}
+		flusher.Flush()
+
+		latest = data
+	}
+
+	for data := range jpegChan {
+		if latest != nil {
+			// The frame sent first might also have been delivered to the
+			// channel, as it was published after subscribing.
+			sameFrame := sameData(data, latest)
+			latest = nil
+			if sameFrame {
+				continue
+			}
+		}
+
+		if writePart(mw, data) != nil {
+			// Viewer is gone.
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// HandleVideo implements video.Handler. It encodes the frame and sends it to
+// all viewers.
+func (s *Server) HandleVideo(frame *video.Frame) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, scale(frame.RGB, s.options.Scale),
+		&jpeg.Options{Quality: s.options.Quality})
+	if err != nil {
+		// Encoding to memory only fails for invalid images. Nothing to send.
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Removing the video handler does not wait for a frame being handled, so
+	// frames might arrive after the last viewer left. Drop them so they do not
+	// become a stale first frame for the next viewer.
+	if s.viewers == 0 {
+		return
+	}
+
+	s.jpegs.Publish(buf.Bytes())
+}
+
+// MaxFrameRate implements video.FrameRateLimiter.
+func (s *Server) MaxFrameRate() float64 {
+	return s.options.MaxFrameRate
+}
+
+func (s *Server) addViewer() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.viewers == 0 {
+		id, err := s.source.AddVideoHandler(s)
+		if err != nil {
+			return fmt.Errorf("error starting video: %w", err)
+		}
+
+		s.handlerID = id
+	}
+
+	s.viewers++
+
+	return nil
+}
+
+func (s *Server) removeViewer() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.viewers--
+
+	if s.viewers == 0 {
+		// Errors are ignored as there is nothing else we can do about them.
+		s.source.RemoveVideoHandler(s.handlerID)
+
+		// Do not show a stale frame to the next viewer.
+		s.jpegs.Reset()
+	}
+}
+
+// sameData returns true if a and b are the same slice.
+func sameData(a, b []byte) bool {
+	return len(a) == len(b) && len(a) > 0 && &a[0] == &b[0]
+}
+
+func writePart(mw *multipart.Writer, data []byte) error {
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":   {"image/jpeg"},
+		"Content-Length": {strconv.Itoa(len(data))},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = pw.Write(data)
+
+	return err
+}
+
+// scale returns the given image scaled by the given factor, using nearest
+// neighbor sampling.
+func scale(img *video.RGB, factor float64) *image.RGBA {
+	if factor == 1 {
+		return img.ToRGBA()
+	}
+
+	bounds := img.Bounds()
+
+	w := int(float64(bounds.Dx()) * factor)
+	h := int(float64(bounds.Dy()) * factor)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/h
+		dst := scaled.Pix[y*scaled.Stride:]
+
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(bounds.Min.X+x*bounds.Dx()/w, srcY)
+			dst[4*x+0] = img.Pix[i+0]
+			dst[4*x+1] = img.Pix[i+1]
+			dst[4*x+2] = img.Pix[i+2]
+			dst[4*x+3] = 255
+		}
+	}
+
+	return scaled
+}
@@ -0,0 +1,359 @@
+package mjpeg
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/modules/video"
+)
+
+// fakeSource sends synthetic frames to its handler while one is registered.
+type fakeSource struct {
+	m        sync.Mutex
+	adds     int
+	removes  int
+	handler  video.Handler
+	quitChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (f *fakeSource) AddVideoHandler(videoHandler video.Handler) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.handler != nil {
+		return -1, fmt.Errorf("handler already registered")
+	}
+
+	f.adds++
+	f.handler = videoHandler
+	f.quitChan = make(chan struct{})
+
+	f.wg.Add(1)
+	go f.sendLoop(videoHandler, f.quitChan)
+
+	return f.adds, nil
+}
+
+func (f *fakeSource) RemoveVideoHandler(id int) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.handler == nil || id != f.adds {
+		return fmt.Errorf("invalid video handler id: %v", id)
+	}
+
+	f.removes++
+	f.handler = nil
+	close(f.quitChan)
+
+	return nil
+}
+
+func (f *fakeSource) counts() (int, int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.adds, f.removes
+}
+
+func (f *fakeSource) sendLoop(videoHandler video.Handler,
+	quitChan chan struct{}) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Honor the handler frame rate limit, as video.Video does.
+	var minInterval time.Duration
+	if limiter, ok := videoHandler.(video.FrameRateLimiter); ok &&
+		limiter.MaxFrameRate() > 0 {
+		minInterval = time.Duration(float64(time.Second) /
+			limiter.MaxFrameRate())
+	}
+
+	var lastSent time.Time
+	for seq := uint64(1); ; seq++ {
+		frame := &video.Frame{
+			RGB:  video.NewRGB(image.Rect(0, 0, 64, 48)),
+			Seq:  seq,
+			Time: time.Now(),
+		}
+
+		if frame.Time.Sub(lastSent) >= minInterval {
+			videoHandler.HandleVideo(frame)
+			lastSent = frame.Time
+		}
+
+		select {
+		case <-ticker.C:
+		case <-quitChan:
+			return
+		}
+	}
+}
+
+func startViewer(t *testing.T, ctx context.Context,
+	url string) *multipart.Reader {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(
+		resp.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("expected multipart/x-mixed-replace, got %q", mediaType)
+	}
+
+	return multipart.NewReader(resp.Body, params["boundary"])
+}
+
+func readJPEG(t *testing.T, mr *multipart.Reader) image.Image {
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if part.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("expected image/jpeg part, got %q",
+			part.Header.Get("Content-Type"))
+	}
+
+	img, err := jpeg.Decode(part)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	return img
+}
+
+func waitForCounts(t *testing.T, source *fakeSource, adds, removes int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		gotAdds, gotRemoves := source.counts()
+		if gotAdds == adds && gotRemoves == removes {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d adds and %d removes, got %d and %d", adds,
+				removes, gotAdds, gotRemoves)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	err := DefaultOptions.Validate()
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	invalid := []Options{
+		{Quality: 0, Scale: 1},
+		{Quality: 101, Scale: 1},
+		{Quality: 75, Scale: 0},
+		{Quality: 75, Scale: 1.5},
+		{Quality: 75, Scale: 1, MaxFrameRate: -1},
+	}
+
+	for _, options := range invalid {
+		_, err := New(&fakeSource{}, options)
+		if err == nil {
+			t.Errorf("expected non-nil error for %+v", options)
+		}
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	source := &fakeSource{}
+	t.Cleanup(source.wg.Wait)
+
+	options := DefaultOptions
+	options.Scale = 0.5
+	options.MaxFrameRate = 0
+
+	s, err := New(source, options)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	waitForCounts(t, source, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mr := startViewer(t, ctx, server.URL)
+
+	for i := 0; i < 3; i++ {
+		img := readJPEG(t, mr)
+		if img.Bounds() != image.Rect(0, 0, 32, 24) {
+			t.Fatalf("expected 32x24 frame, got %v", img.Bounds())
+		}
+	}
+
+	waitForCounts(t, source, 1, 0)
+
+	cancel()
+
+	waitForCounts(t, source, 1, 1)
+
+	if s.Viewers() != 0 {
+		t.Fatalf("expected no viewers, got %d", s.Viewers())
+	}
+}
+
+func TestServer_MultipleViewers(t *testing.T) {
+	source := &fakeSource{}
+	t.Cleanup(source.wg.Wait)
+
+	s, err := New(source, DefaultOptions)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+
+	mr1 := startViewer(t, ctx1, server.URL)
+	mr2 := startViewer(t, ctx2, server.URL)
+
+	readJPEG(t, mr1)
+	readJPEG(t, mr2)
+
+	// Both viewers share a single handler.
+	waitForCounts(t, source, 1, 0)
+
+	cancel1()
+
+	readJPEG(t, mr2)
+
+	cancel2()
+
+	waitForCounts(t, source, 1, 1)
+}
+
+func TestServer_MaxFrameRate(t *testing.T) {
+	source := &fakeSource{}
+	t.Cleanup(source.wg.Wait)
+
+	options := DefaultOptions
+	options.MaxFrameRate = 20
+
+	s, err := New(source, options)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if s.MaxFrameRate() != options.MaxFrameRate {
+		t.Fatalf("expected max frame rate %f, got %f", options.MaxFrameRate,
+			s.MaxFrameRate())
+	}
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mr := startViewer(t, ctx, server.URL)
+
+	readJPEG(t, mr)
+
+	// The source sends a frame every 10ms, but only one every 50ms is
+	// streamed.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		readJPEG(t, mr)
+	}
+
+	elapsed := time.Since(start)
+	if elapsed < 200*time.Millisecond {
+		t.Fatalf("expected 5 frames to take at least 200ms, got %s", elapsed)
+	}
+}
+
+func TestServer_HandleVideoWithoutViewers(t *testing.T) {
+	s, err := New(&fakeSource{}, DefaultOptions)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// A late frame after the last viewer left is not kept for the next one.
+	s.HandleVideo(&video.Frame{
+		RGB: video.NewRGB(image.Rect(0, 0, 64, 48)),
+	})
+
+	if _, ok := s.jpegs.Latest(); ok {
+		t.Fatalf("expected no latest frame")
+	}
+}
+
+func TestSameData(t *testing.T) {
+	a := []byte{1, 2, 3}
+	b := []byte{1, 2, 3}
+
+	if !sameData(a, a) {
+		t.Errorf("expected slice to be the same as itself")
+	}
+
+	if sameData(a, b) {
+		t.Errorf("expected equal copies not to be the same")
+	}
+
+	if sameData(a, a[:2]) {
+		t.Errorf("expected slices with different lengths not to be the same")
+	}
+
+	if sameData(nil, nil) {
+		t.Errorf("expected empty slices not to be the same")
+	}
+}
+
+func TestServer_SourceError(t *testing.T) {
+	source := &fakeSource{}
+	source.handler = &Server{}
+
+	s, err := New(source, DefaultOptions)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable,
+			rec.Code)
+	}
+}
@@ -47,3 +47,25 @@ func (im *RGB) Set(x, y int, c color.Color) {
 func (im *RGB) PixOffset(x, y int) int {
 	return (y-im.Rect.Min.Y)*im.Stride + (x-im.Rect.Min.X)*3
 }
+
+// ToRGBA returns a copy of the image as an *image.RGBA, which most image
+// encoders and processing libraries handle a lot faster than a generic
+// image.Image.
+func (im *RGB) ToRGBA() *image.RGBA {
+	rgba := image.NewRGBA(im.Rect)
+
+	w := im.Rect.Dx()
+	for y := 0; y < im.Rect.Dy(); y++ {
+		src := im.Pix[y*im.Stride : y*im.Stride+3*w]
+		dst := rgba.Pix[y*rgba.Stride : y*rgba.Stride+4*w]
+
+		for i, j := 0, 0; i < len(src); i, j = i+3, j+4 {
+			dst[j+0] = src[i+0]
+			dst[j+1] = src[i+1]
+			dst[j+2] = src[i+2]
+			dst[j+3] = 255
+		}
+	}
+
+	return rgba
+}
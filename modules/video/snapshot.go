@@ -0,0 +1,147 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ImageFormat is the format snapshots are encoded with.
+type ImageFormat int
+
+const (
+	ImageFormatJPEG ImageFormat = iota
+	ImageFormatPNG
+)
+
+func (i ImageFormat) String() string {
+	switch i {
+	case ImageFormatJPEG:
+		return "JPEG"
+	case ImageFormatPNG:
+		return "PNG"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int(i))
+}
+
+// Extension returns the usual file name extension for the format.
+func (i ImageFormat) Extension() string {
+	switch i {
+	case ImageFormatJPEG:
+		return ".jpg"
+	case ImageFormatPNG:
+		return ".png"
+	}
+
+	return ""
+}
+
+// snapshotJPEGQuality is the JPEG quality used for snapshots.
+const snapshotJPEGQuality = 90
+
+// Snapshot waits for the next video frame and returns a copy of it. A
+// temporary handler is registered only while waiting. Returns the frame and a
+// nil error on success and nil and a non-nil error on failure (including the
+// context being done before a frame arrives).
+func (v *Video) Snapshot(ctx context.Context) (*RGB, error) {
+	frameChan := make(chan *Frame, 1)
+
+	id, err := v.AddVideoHandler(snapshotHandler(frameChan))
+	if err != nil {
+		return nil, fmt.Errorf("error taking snapshot: %w", err)
+	}
+	defer v.RemoveVideoHandler(id)
+
+	select {
+	case frame := <-frameChan:
+		return frame.RGB, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("error taking snapshot: %w", ctx.Err())
+	}
+}
+
+// SaveSnapshot takes a snapshot (see Snapshot) and saves it to the given path
+// encoded in the given format. Returns a nil error on success and a non-nil
+// error on failure.
+func (v *Video) SaveSnapshot(ctx context.Context, path string,
+	format ImageFormat) error {
+	img, err := v.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failure never leaves a truncated
+	// image behind.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating snapshot file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	err = EncodeImage(f, img, format)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	// Temporary files are only readable by us.
+	err = f.Chmod(0644)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// EncodeImage encodes the given image to the given writer in the given format.
+// Returns a nil error on success and a non-nil error on failure.
+func EncodeImage(w io.Writer, img *RGB, format ImageFormat) error {
+	var err error
+	switch format {
+	case ImageFormatJPEG:
+		err = jpeg.Encode(w, img.ToRGBA(), &jpeg.Options{
+			Quality: snapshotJPEGQuality})
+	case ImageFormatPNG:
+		err = png.Encode(w, img.ToRGBA())
+	default:
+		return fmt.Errorf("invalid image format: %s", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error encoding %s image: %w", format, err)
+	}
+
+	return nil
+}
+
+// snapshotHandler sends a copy of the first frame it gets to its channel and
+// ignores all others.
+type snapshotHandler chan *Frame
+
+func (s snapshotHandler) HandleVideo(frame *Frame) {
+	if len(s) > 0 {
+		// Already got a frame.
+		return
+	}
+
+	select {
+	case s <- frame.Clone():
+	default:
+	}
+}
@@ -0,0 +1,280 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/internal/robot/service/servicetest"
+	"github.com/brunoga/robomaster2/internal/robot/service/unitybridge"
+	"github.com/brunoga/robomaster2/support"
+)
+
+// newTestImage returns a small image with a different color in each pixel.
+func newTestImage(rect image.Rectangle) *RGB {
+	img := NewRGB(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 10),
+				uint8(x + y), 255})
+		}
+	}
+
+	return img
+}
+
+// feedFrames sends frames filled with the given value to the given Video until
+// the given channel is closed.
+func feedFrames(v *Video, value byte, done <-chan struct{}) {
+	data := bytes.Repeat([]byte{value}, v.TextureInfo().FrameSize())
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+
+		v.onFrame(data)
+	}
+}
+
+func TestRGB_ToRGBA(t *testing.T) {
+	img := newTestImage(image.Rect(2, 3, 6, 5))
+
+	rgba := img.ToRGBA()
+
+	if rgba.Bounds() != img.Bounds() {
+		t.Fatalf("expected bounds %s, got %s", img.Bounds(), rgba.Bounds())
+	}
+
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if rgba.RGBAAt(x, y) != img.At(x, y) {
+				t.Fatalf("expected %v at (%d, %d), got %v", img.At(x, y), x,
+					y, rgba.RGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	img := newTestImage(image.Rect(0, 0, 8, 4))
+
+	// PNG is lossless.
+	var buf bytes.Buffer
+	err := EncodeImage(&buf, img, ImageFormatPNG)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			r1, g1, b1, a1 := decoded.At(x, y).RGBA()
+			r2, g2, b2, a2 := img.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Fatalf("expected %v at (%d, %d), got %v", img.At(x, y), x,
+					y, decoded.At(x, y))
+			}
+		}
+	}
+
+	buf.Reset()
+	err = EncodeImage(&buf, img, ImageFormatJPEG)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	decoded, err = jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Fatalf("expected bounds %s, got %s", img.Bounds(),
+			decoded.Bounds())
+	}
+
+	err = EncodeImage(&buf, img, ImageFormat(2))
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+}
+
+func TestVideo_Snapshot(t *testing.T) {
+	b := servicetest.InstallBridge(t)
+
+	v := New(support.NewLogger(nil, nil, nil, nil))
+
+	done := make(chan struct{})
+	defer close(done)
+	go feedFrames(v, 42, done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	img, err := v.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if img.Bounds() != defaultTextureInfo.Rect() {
+		t.Fatalf("expected bounds %s, got %s", defaultTextureInfo.Rect(),
+			img.Bounds())
+	}
+	if img.Pix[0] != 42 || img.Pix[len(img.Pix)-1] != 42 {
+		t.Fatalf("expected frame data, got %d ... %d", img.Pix[0],
+			img.Pix[len(img.Pix)-1])
+	}
+
+	// Video was only running while taking the snapshot.
+	if b.Count(unitybridge.StartVideo) != 1 {
+		t.Fatalf("expected video to be started once, got %d",
+			b.Count(unitybridge.StartVideo))
+	}
+	if b.Count(unitybridge.StopVideo) != 1 {
+		t.Fatalf("expected video to be stopped once, got %d",
+			b.Count(unitybridge.StopVideo))
+	}
+}
+
+func TestVideo_Snapshot_OtherHandler(t *testing.T) {
+	b := servicetest.InstallBridge(t)
+
+	v := New(support.NewLogger(nil, nil, nil, nil))
+
+	id, err := v.AddVideoHandler(newTestHandler(false))
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go feedFrames(v, 42, done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = v.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	// The other handler keeps video running.
+	if b.Count(unitybridge.StopVideo) != 0 {
+		t.Fatalf("expected video not to be stopped, got %d stops",
+			b.Count(unitybridge.StopVideo))
+	}
+
+	err = v.RemoveVideoHandler(id)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+
+	if b.Count(unitybridge.StartVideo) != 1 ||
+		b.Count(unitybridge.StopVideo) != 1 {
+		t.Fatalf("expected video to be started and stopped once, got %v",
+			b.Events())
+	}
+}
+
+func TestVideo_Snapshot_Timeout(t *testing.T) {
+	b := servicetest.InstallBridge(t)
+
+	v := New(support.NewLogger(nil, nil, nil, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	_, err := v.Snapshot(ctx)
+	if err == nil {
+		t.Fatalf("expected non-nil error, got nil")
+	}
+
+	if b.Count(unitybridge.StopVideo) != 1 {
+		t.Fatalf("expected video to be stopped, got %v", b.Events())
+	}
+}
+
+func TestVideo_SaveSnapshot(t *testing.T) {
+	servicetest.InstallBridge(t)
+
+	v := New(support.NewLogger(nil, nil, nil, nil))
+
+	done := make(chan struct{})
+	defer close(done)
+	go feedFrames(v, 42, done)
+
+	dir := t.TempDir()
+
+	for _, format := range []ImageFormat{ImageFormatJPEG, ImageFormatPNG} {
+		path := filepath.Join(dir, "snapshot"+format.Extension())
+
+		ctx, cancel := context.WithTimeout(context.Background(),
+			2*time.Second)
+
+		err := v.SaveSnapshot(ctx, path, format)
+
+		cancel()
+
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %q", format, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %q", format, err)
+		}
+		if info.Mode().Perm() != 0644 {
+			t.Fatalf("%s: expected mode 0644, got %s", format,
+				info.Mode().Perm())
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %q", format, err)
+		}
+
+		config, decodedFormat, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %q", format, err)
+		}
+
+		if config.Width != defaultTextureInfo.Width ||
+			config.Height != defaultTextureInfo.Height {
+			t.Fatalf("%s: expected %dx%d image, got %dx%d", format,
+				defaultTextureInfo.Width, defaultTextureInfo.Height,
+				config.Width, config.Height)
+		}
+
+		expectedFormat := map[ImageFormat]string{
+			ImageFormatJPEG: "jpeg",
+			ImageFormatPNG:  "png",
+		}[format]
+		if decodedFormat != expectedFormat {
+			t.Fatalf("expected %s image, got %s", expectedFormat,
+				decodedFormat)
+		}
+	}
+
+	// Only the snapshots are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected nil error, got %q", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(entries))
+	}
+}
@@ -0,0 +1,196 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brunoga/robomaster2/internal/robot/service"
+	"github.com/brunoga/robomaster2/internal/robot/service/dji"
+)
+
+// PixelFormat is the pixel layout of video frames.
+type PixelFormat int
+
+const (
+	PixelFormatUnknown PixelFormat = iota
+
+	// 3 bytes per pixel, in R, G, B order.
+	PixelFormatRGB
+
+	// 4 bytes per pixel, in R, G, B, A order.
+	PixelFormatRGBA
+)
+
+func (p PixelFormat) String() string {
+	switch p {
+	case PixelFormatRGB:
+		return "RGB"
+	case PixelFormatRGBA:
+		return "RGBA"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int(p))
+}
+
+// BytesPerPixel returns the number of bytes used by each pixel in this format
+// or 0 if the format is unknown.
+func (p PixelFormat) BytesPerPixel() int {
+	switch p {
+	case PixelFormatRGB:
+		return 3
+	case PixelFormatRGBA:
+		return 4
+	}
+
+	return 0
+}
+
+// TextureInfo describes the video frames being transmitted.
+type TextureInfo struct {
+	Width  int
+	Height int
+	Format PixelFormat
+}
+
+// Default texture information, used until the robot reports it.
+var defaultTextureInfo = TextureInfo{1280, 720, PixelFormatRGB}
+
+func (t TextureInfo) String() string {
+	return fmt.Sprintf("%dx%d %s", t.Width, t.Height, t.Format)
+}
+
+// Rect returns the bounds of frames with this texture information.
+func (t TextureInfo) Rect() image.Rectangle {
+	return image.Rect(0, 0, t.Width, t.Height)
+}
+
+// FrameSize returns the size in bytes of frames with this texture information.
+func (t TextureInfo) FrameSize() int {
+	return t.Width * t.Height * t.Format.BytesPerPixel()
+}
+
+var textureNumberRegexp = regexp.MustCompile(`\d+`)
+
+// Frame dimensions the robot transmits video with (see Resolution).
+var knownTextureSizes = []image.Point{
+	{1280, 720},
+	{1920, 1080},
+}
+
+// textureInfoForFrameSize returns texture information for frames with the
+// given size in bytes. The dimensions in the given texture information are
+// tried first and then the known ones, with all pixel formats. Returns the
+// texture information and true on success and an empty TextureInfo and false
+// if no texture information matches.
+func textureInfoForFrameSize(frameSize int,
+	current TextureInfo) (TextureInfo, bool) {
+	sizes := append([]image.Point{{current.Width, current.Height}},
+		knownTextureSizes...)
+	for _, size := range sizes {
+		for _, format := range []PixelFormat{PixelFormatRGB,
+			PixelFormatRGBA} {
+			textureInfo := TextureInfo{size.X, size.Y, format}
+			if textureInfo.FrameSize() == frameSize {
+				return textureInfo, true
+			}
+		}
+	}
+
+	return TextureInfo{}, false
+}
+
+// parseTextureInfo parses the texture information reported by the
+// GetNativeTexture event. The first two numbers in it are the frame width and
+// height. The pixel format is RGB unless RGBA is mentioned. The actual layout
+// is undocumented, so frames have the final word on it (see
+// textureInfoForFrameSize). Returns the
+// texture information and a nil error on success and an empty TextureInfo and
+// a non-nil error on failure.
+func parseTextureInfo(data []byte) (TextureInfo, error) {
+	numbers := textureNumberRegexp.FindAllString(string(data), 2)
+	if len(numbers) != 2 {
+		return TextureInfo{}, fmt.Errorf("no texture dimensions in %q",
+			string(data))
+	}
+
+	width, err := strconv.Atoi(numbers[0])
+	if err != nil {
+		return TextureInfo{}, fmt.Errorf("invalid texture width: %w", err)
+	}
+
+	height, err := strconv.Atoi(numbers[1])
+	if err != nil {
+		return TextureInfo{}, fmt.Errorf("invalid texture height: %w", err)
+	}
+
+	if width <= 0 || height <= 0 {
+		return TextureInfo{}, fmt.Errorf("invalid texture dimensions %dx%d",
+			width, height)
+	}
+
+	format := PixelFormatRGB
+	if strings.Contains(strings.ToUpper(string(data)), "RGBA") {
+		format = PixelFormatRGBA
+	}
+
+	return TextureInfo{width, height, format}, nil
+}
+
+// Resolution is the resolution video is transmitted with.
+type Resolution int64
+
+const (
+	Resolution720p Resolution = iota
+	Resolution1080p
+)
+
+func (r Resolution) String() string {
+	switch r {
+	case Resolution720p:
+		return "720p"
+	case Resolution1080p:
+		return "1080p"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", int64(r))
+}
+
+// TextureInfo returns the information about the video frames currently being
+// transmitted.
+func (v *Video) TextureInfo() TextureInfo {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.textureInfo
+}
+
+// Resolution returns the current video transmission Resolution. Returns the
+// resolution and a nil error on success and Resolution720p and a non-nil error
+// on failure.
+func (v *Video) Resolution(ctx context.Context) (Resolution, error) {
+	value, err := service.GetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJICameraVideoFormat)
+
+	return Resolution(value), err
+}
+
+// SetResolution sets the video transmission Resolution. The new texture
+// information is requested afterwards so frame buffers are resized and
+// handlers are notified once frames with the new resolution arrive. Returns a
+// nil error on success and a non-nil error on failure.
+func (v *Video) SetResolution(ctx context.Context, resolution Resolution) error {
+	err := service.SetNumberForKeySync(ctx,
+		service.DJICommandControllerInstance(), dji.DJICameraVideoFormat,
+		int64(resolution))
+	if err != nil {
+		return fmt.Errorf("error setting video resolution: %w", err)
+	}
+
+	v.requestTextureInfo()
+
+	return nil
+}
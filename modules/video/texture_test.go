@@ -0,0 +1,220 @@
+package video
+
+import (
+	"bytes"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster2/support"
+)
+
+func TestParseTextureInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected TextureInfo
+		valid    bool
+	}{
+		{"Plain", "1280x720", TextureInfo{1280, 720, PixelFormatRGB}, true},
+		{"Separators", "width=1920, height=1080",
+			TextureInfo{1920, 1080, PixelFormatRGB}, true},
+		{"ExtraNumbers", "640 480 3 0", TextureInfo{640, 480, PixelFormatRGB},
+			true},
+		{"RGBA", "1280x720 rgba", TextureInfo{1280, 720, PixelFormatRGBA},
+			true},
+		{"Empty", "", TextureInfo{}, false},
+		{"SingleNumber", "1280", TextureInfo{}, false},
+		{"ZeroWidth", "0x720", TextureInfo{}, false},
+		{"Overflow", "99999999999999999999x720", TextureInfo{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseTextureInfo([]byte(test.data))
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("expected non-nil error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected nil error, got %q", err)
+			}
+
+			if got != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestTextureInfo_FrameSize(t *testing.T) {
+	tests := []struct {
+		textureInfo TextureInfo
+		expected    int
+	}{
+		{TextureInfo{1280, 720, PixelFormatRGB}, 1280 * 720 * 3},
+		{TextureInfo{1920, 1080, PixelFormatRGBA}, 1920 * 1080 * 4},
+		{TextureInfo{1280, 720, PixelFormatUnknown}, 0},
+	}
+
+	for _, test := range tests {
+		got := test.textureInfo.FrameSize()
+		if got != test.expected {
+			t.Errorf("%v: expected %d, got %d", test.textureInfo, test.expected,
+				got)
+		}
+	}
+}
+
+func TestCopyPixels(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      []byte
+		format   PixelFormat
+		expected []byte
+	}{
+		{"RGB", []byte{1, 2, 3, 4, 5, 6}, PixelFormatRGB,
+			[]byte{1, 2, 3, 4, 5, 6}},
+		{"RGBA", []byte{1, 2, 3, 255, 4, 5, 6, 128}, PixelFormatRGBA,
+			[]byte{1, 2, 3, 4, 5, 6}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := make([]byte, len(test.expected))
+
+			copyPixels(dst, test.src, test.format)
+
+			if !bytes.Equal(dst, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, dst)
+			}
+		})
+	}
+}
+
+func TestTextureInfoForFrameSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameSize int
+		current   TextureInfo
+		expected  TextureInfo
+		ok        bool
+	}{
+		{"Current", 640 * 480 * 3, TextureInfo{640, 480, PixelFormatRGB},
+			TextureInfo{640, 480, PixelFormatRGB}, true},
+		{"CurrentOtherFormat", 640 * 480 * 4,
+			TextureInfo{640, 480, PixelFormatRGB},
+			TextureInfo{640, 480, PixelFormatRGBA}, true},
+		{"Known720p", 1280 * 720 * 3, TextureInfo{3, 2, PixelFormatRGB},
+			TextureInfo{1280, 720, PixelFormatRGB}, true},
+		{"Known1080pRGBA", 1920 * 1080 * 4, TextureInfo{3, 2, PixelFormatRGB},
+			TextureInfo{1920, 1080, PixelFormatRGBA}, true},
+		{"Unknown", 100, TextureInfo{3, 2, PixelFormatRGB}, TextureInfo{},
+			false},
+	}
+
+	for _, test := range tests {
+		got, ok := textureInfoForFrameSize(test.frameSize, test.current)
+		if ok != test.ok || got != test.expected {
+			t.Errorf("%s: expected %v, %t, got %v, %t", test.name,
+				test.expected, test.ok, got, ok)
+		}
+	}
+}
+
+// resolutionHandler records the bounds of delivered frames and the reported
+// resolutions.
+type resolutionHandler struct {
+	m           sync.Mutex
+	bounds      []image.Rectangle
+	resolutions []TextureInfo
+}
+
+func (r *resolutionHandler) HandleVideo(frame *Frame) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.bounds = append(r.bounds, frame.Rect)
+}
+
+func (r *resolutionHandler) HandleResolution(info TextureInfo) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.resolutions = append(r.resolutions, info)
+}
+
+func TestVideo_OnFrame_TextureInfoMismatch(t *testing.T) {
+	v := New(support.NewLogger(nil, nil, nil, nil))
+
+	// Pretend a texture info request is pending so none is sent.
+	v.textureInfoPending = true
+
+	h := &resolutionHandler{}
+	runner := newHandlerRunner(h, HandlerOptions{Policy: DeliveryFIFO,
+		QueueSize: 10})
+	defer runner.stop()
+
+	v.videoHandlers[0] = runner
+
+	// The first two numbers are not the frame dimensions.
+	payload := []byte("id 3 ver 2 width 1280 height 720")
+
+	v.onTextureInfo(payload)
+
+	if v.TextureInfo() != (TextureInfo{3, 2, PixelFormatRGB}) {
+		t.Fatalf("expected parsed texture info, got %s", v.TextureInfo())
+	}
+
+	expected := TextureInfo{1280, 720, PixelFormatRGB}
+
+	// Frames are not dropped. Their size tells the actual texture info.
+	v.onFrame(make([]byte, expected.FrameSize()))
+
+	if v.TextureInfo() != expected {
+		t.Fatalf("expected texture info %s, got %s", expected,
+			v.TextureInfo())
+	}
+
+	// Reports that do not match the frames are ignored from now on.
+	v.onTextureInfo(payload)
+
+	if v.TextureInfo() != expected {
+		t.Fatalf("expected texture info %s, got %s", expected,
+			v.TextureInfo())
+	}
+
+	v.onFrame(make([]byte, expected.FrameSize()))
+
+	// Frames nothing can be made of are still dropped.
+	v.onFrame(make([]byte, 100))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runner.Stats().Delivered != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 delivered frames, got %+v", runner.Stats())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for _, bounds := range h.bounds {
+		if bounds != expected.Rect() {
+			t.Fatalf("expected frame bounds %s, got %s", expected.Rect(),
+				bounds)
+		}
+	}
+
+	if len(h.resolutions) == 0 ||
+		h.resolutions[len(h.resolutions)-1] != expected {
+		t.Fatalf("expected last resolution %s, got %v", expected,
+			h.resolutions)
+	}
+}
@@ -3,8 +3,8 @@ package video
 import (
 	"encoding/binary"
 	"fmt"
-	"image"
 	"sync"
+	"time"
 
 	"github.com/brunoga/robomaster2/internal/robot/service"
 	"github.com/brunoga/robomaster2/internal/robot/service/dji"
@@ -15,17 +15,27 @@ import (
 type Video struct {
 	logger *support.Logger
 
-	m             sync.Mutex
-	videoHandlers map[int]Handler
-	img           *RGB
+	m                  sync.Mutex
+	videoHandlers      map[int]*handlerRunner
+	nextHandlerID      int
+	textureInfo        TextureInfo
+	textureInfoPending bool
+	frameSize          int
+	framePool          *framePool
+	seq                uint64
 }
 
 func New(logger *support.Logger) *Video {
 	return &Video{
 		logger,
 		sync.Mutex{},
-		make(map[int]Handler),
-		NewRGB(image.Rect(0, 0, 1280, 720)),
+		make(map[int]*handlerRunner),
+		0,
+		defaultTextureInfo,
+		false,
+		0,
+		newFramePool(defaultTextureInfo.Rect()),
+		0,
 	}
 }
 
@@ -39,42 +49,86 @@ func (v *Video) Start() error {
 	return nil
 }
 
+// Stop stops video reception and unregisters all video handlers. Frames queued
+// for them are dropped.
 func (v *Video) Stop() error {
 	ub := unitybridge.DJIUnityBridgeInstance()
 
 	ub.UnregisterEventHandler(v)
 
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if len(v.videoHandlers) == 0 {
+		return nil
+	}
+
+	for id, runner := range v.videoHandlers {
+		runner.stop()
+		delete(v.videoHandlers, id)
+	}
+
+	ub.SendEventWithoutDataOrTag(
+		unitybridge.NewDJIUnityEventWithType(unitybridge.StopVideo))
+
 	return nil
 }
 
+// AddVideoHandler registers the given handler to receive video frames with
+// DefaultHandlerOptions. Returns the handler ID and a nil error on success and
+// a non-nil error on failure.
 func (v *Video) AddVideoHandler(videoHandler Handler) (int, error) {
+	return v.AddVideoHandlerWithOptions(videoHandler, DefaultHandlerOptions)
+}
+
+// AddVideoHandlerWithOptions registers the given handler to receive video
+// frames delivered according to the given options. Returns the handler ID and
+// a nil error on success and a non-nil error on failure.
+func (v *Video) AddVideoHandlerWithOptions(videoHandler Handler,
+	options HandlerOptions) (int, error) {
+	err := options.Validate()
+	if err != nil {
+		return -1, fmt.Errorf("error adding video handler: %w", err)
+	}
+
 	v.m.Lock()
 	defer v.m.Unlock()
 
-	id := len(v.videoHandlers)
-
-	if id == 0 {
+	if len(v.videoHandlers) == 0 {
 		unitybridge.DJIUnityBridgeInstance().SendEventWithoutDataOrTag(
 			unitybridge.NewDJIUnityEventWithType(unitybridge.StartVideo))
+
+		// Video is (re)starting, so any previous request for texture
+		// information might never be answered.
+		v.textureInfoPending = false
 	}
 
-	v.videoHandlers[id] = videoHandler
+	// IDs are never reused so a stale ID can not remove a different handler.
+	id := v.nextHandlerID
+	v.nextHandlerID++
 
-	unitybridge.DJIUnityBridgeInstance().SendEventWithoutDataOrTag(
-		unitybridge.NewDJIUnityEventWithType(unitybridge.GetNativeTexture))
+	v.videoHandlers[id] = newHandlerRunner(videoHandler, options)
+
+	v.requestTextureInfoLocked()
 
 	return id, nil
 }
 
+// RemoveVideoHandler unregisters the handler with the given ID. Frames queued
+// for it are dropped. A frame being handled is not waited for, so this can be
+// called from the handler itself. Returns a nil error on success and a non-nil
+// error on failure.
 func (v *Video) RemoveVideoHandler(id int) error {
 	v.m.Lock()
 	defer v.m.Unlock()
 
-	_, ok := v.videoHandlers[id]
+	runner, ok := v.videoHandlers[id]
 	if !ok {
 		return fmt.Errorf("invalid video handler id: %v", id)
 	}
 
+	runner.stop()
+
 	delete(v.videoHandlers, id)
 
 	if len(v.videoHandlers) == 0 {
@@ -85,28 +139,179 @@ func (v *Video) RemoveVideoHandler(id int) error {
 	return nil
 }
 
+// HandlerStats returns frame delivery statistics for the handler with the
+// given ID. Returns the statistics and a nil error on success and empty
+// statistics and a non-nil error on failure.
+func (v *Video) HandlerStats(id int) (HandlerStats, error) {
+	v.m.Lock()
+	runner, ok := v.videoHandlers[id]
+	v.m.Unlock()
+
+	if !ok {
+		return HandlerStats{}, fmt.Errorf("invalid video handler id: %v", id)
+	}
+
+	return runner.Stats(), nil
+}
+
 func (v *Video) OnEventCallback(event *unitybridge.DJIUnityEvent, data []byte, tag uint64) {
 	switch event.Type() {
 	case unitybridge.GetNativeTexture:
-		v.logger.INFO("GetNativeTexture: %v", string(data))
-		// TODO(bga): Set correct texture resolution.
-		v.m.Lock()
-		v.img = NewRGB(image.Rect(0, 0, 1280, 720))
-		v.m.Unlock()
+		v.logger.TRACE("GetNativeTexture: %v", string(data))
+		v.onTextureInfo(data)
 	case unitybridge.VideoTransferSpeed:
 		value := binary.NativeEndian.Uint64(data)
 		v.logger.INFO("VideoTransferSpeed: %v", value)
 		// TODO(bga): What to do here?
 	case unitybridge.VideoDataRecv:
-		v.m.Lock()
+		v.onFrame(data)
+	}
+}
+
+func (v *Video) onFrame(data []byte) {
+	now := time.Now()
+
+	v.m.Lock()
 
-		v.img.Pix = data
+	v.seq++
+	seq := v.seq
 
-		for _, videoHandler := range v.videoHandlers {
-			videoHandler.HandleVideo(v.img)
+	var changed bool
+	textureInfo := v.textureInfo
+	if len(data) != textureInfo.FrameSize() {
+		// The texture information might be outdated or wrongly parsed, so
+		// try to figure it out from the frame itself.
+		observed, ok := textureInfoForFrameSize(len(data), textureInfo)
+		if !ok {
+			// Nothing we can make an image out of. Drop it and ask for up to
+			// date information.
+			v.logger.TRACE("Dropping %d bytes frame (expected %d bytes "+
+				"for %s)", len(data), textureInfo.FrameSize(), textureInfo)
+			v.requestTextureInfoLocked()
+			v.m.Unlock()
+			return
 		}
 
+		v.logger.WARNING("Texture info %s does not match %d bytes frames. "+
+			"Using %s.", textureInfo, len(data), observed)
+
+		v.setTextureInfoLocked(observed)
+
+		textureInfo = observed
+		changed = true
+	}
+
+	v.frameSize = len(data)
+
+	frame := v.framePool.get()
+	runners := v.runnersLocked()
+
+	v.m.Unlock()
+
+	// The bridge owns data, so it is always copied to a frame we own.
+	copyPixels(frame.Pix, data, textureInfo.Format)
+	frame.Seq = seq
+	frame.Time = now
+
+	for _, runner := range runners {
+		if changed {
+			runner.enqueueTextureInfo(textureInfo)
+		}
+
+		runner.enqueueFrame(frame)
+	}
+
+	frame.Release()
+}
+
+func (v *Video) onTextureInfo(data []byte) {
+	textureInfo, err := parseTextureInfo(data)
+	if err != nil {
+		v.logger.ERROR("Error parsing texture info: %v", err)
+		return
+	}
+
+	v.m.Lock()
+
+	v.textureInfoPending = false
+
+	if textureInfo == v.textureInfo {
 		v.m.Unlock()
+		return
+	}
+
+	if v.frameSize != 0 && textureInfo.FrameSize() != v.frameSize {
+		// Frames have the final word. If the resolution actually changed,
+		// frames with the new size will tell.
+		v.logger.WARNING("Ignoring texture info %s that does not match %d "+
+			"bytes frames", textureInfo, v.frameSize)
+		v.m.Unlock()
+		return
+	}
+
+	v.setTextureInfoLocked(textureInfo)
+
+	runners := v.runnersLocked()
+
+	v.m.Unlock()
+
+	for _, runner := range runners {
+		runner.enqueueTextureInfo(textureInfo)
+	}
+}
+
+// setTextureInfoLocked makes the given texture information the current one,
+// resizing frame buffers.
+func (v *Video) setTextureInfoLocked(textureInfo TextureInfo) {
+	v.logger.INFO("Video resolution changed from %s to %s", v.textureInfo,
+		textureInfo)
+
+	v.textureInfo = textureInfo
+	v.framePool = newFramePool(textureInfo.Rect())
+}
+
+func (v *Video) runnersLocked() []*handlerRunner {
+	runners := make([]*handlerRunner, 0, len(v.videoHandlers))
+	for _, runner := range v.videoHandlers {
+		runners = append(runners, runner)
+	}
+
+	return runners
+}
+
+func (v *Video) requestTextureInfo() {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	v.requestTextureInfoLocked()
+}
+
+// requestTextureInfoLocked asks for the current texture information unless a
+// request is already pending.
+func (v *Video) requestTextureInfoLocked() {
+	if v.textureInfoPending {
+		return
+	}
+
+	v.textureInfoPending = true
+
+	unitybridge.DJIUnityBridgeInstance().SendEventWithoutDataOrTag(
+		unitybridge.NewDJIUnityEventWithType(unitybridge.GetNativeTexture))
+}
+
+// copyPixels copies the given frame data in the given format to the given RGB
+// pixel buffer.
+func copyPixels(dst, src []byte, format PixelFormat) {
+	if format == PixelFormatRGB {
+		copy(dst, src)
+		return
+	}
+
+	// RGBA. Drop the alpha channel.
+	for i, j := 0, 0; j < len(src); i, j = i+3, j+4 {
+		dst[i+0] = src[j+0]
+		dst[i+1] = src[j+1]
+		dst[i+2] = src[j+2]
 	}
 }
 